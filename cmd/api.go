@@ -0,0 +1,168 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// apiClient is a small REST client for the parts of the vTM API that go-vtm
+// does not cover, such as raw configuration documents, status and statistics.
+type apiClient struct {
+	conn connection
+	http *http.Client
+}
+
+type apiChildren struct {
+	Children []struct {
+		Name string `json:"name"`
+		Href string `json:"href"`
+	} `json:"children"`
+}
+
+func newAPIClient(conn connection) *apiClient {
+	return &apiClient{conn: conn, http: newHTTPClient()}
+}
+
+func initAPIClient() *apiClient {
+	api, err := connectAPI()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return api
+}
+
+// connectAPI is initAPIClient returning errors instead of exiting.
+func connectAPI() (*apiClient, error) {
+	if offlinePath != "" {
+		return nil, errors.New("--offline can only be used with read-only commands")
+	}
+
+	conn, err := resolveConnection()
+	if err != nil {
+		return nil, err
+	}
+
+	return newAPIClient(conn), nil
+}
+
+// url returns the absolute URL of path below the versioned API root,
+// e.g. "config/active/pools".
+func (a *apiClient) url(path string) string {
+	return strings.TrimSuffix(a.conn.URL, "/") + "/api/tm/" + a.conn.Version + "/" + strings.TrimPrefix(path, "/")
+}
+
+func (a *apiClient) newRequest(method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, a.url(path), body)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(a.conn.User, a.conn.Pass)
+
+	return req, nil
+}
+
+// send sends req and returns the response if the API reported success. The
+// caller must close the response body.
+func (a *apiClient) send(req *http.Request) (*http.Response, error) {
+	resp, err := a.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("%s %s: %s %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	return resp, nil
+}
+
+// do sends a request with an optional body of the given content type.
+func (a *apiClient) do(method, path, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := a.newRequest(method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	return a.send(req)
+}
+
+func (a *apiClient) get(path string) ([]byte, error) {
+	resp, err := a.do("GET", path, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+func (a *apiClient) putJSON(path string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	resp, err := a.do("PUT", path, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
+
+func (a *apiClient) getJSON(path string, v interface{}) error {
+	data, err := a.get(path)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// children returns the names of the child resources listed at path.
+func (a *apiClient) children(path string) ([]string, error) {
+	var c apiChildren
+	if err := a.getJSON(path, &c); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(c.Children))
+	for _, child := range c.Children {
+		names = append(names, child.Name)
+	}
+
+	return names, nil
+}
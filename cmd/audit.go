@@ -0,0 +1,162 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/spf13/viper"
+)
+
+// auditRecord is one line of the audit log. Every object modification and
+// backup operation sent to a traffic manager is logged, whether it succeeded
+// or not.
+type auditRecord struct {
+	Time     time.Time       `json:"time"`
+	User     string          `json:"user"`
+	SudoUser string          `json:"sudo_user,omitempty"`
+	Profile  string          `json:"profile,omitempty"`
+	URL      string          `json:"url"`
+	Command  string          `json:"command"`
+	Action   string          `json:"action"`
+	Object   string          `json:"object"`
+	Field    string          `json:"field,omitempty"`
+	Before   json.RawMessage `json:"before,omitempty"`
+	After    json.RawMessage `json:"after,omitempty"`
+	Result   string          `json:"result"`
+	Freeze   string          `json:"freeze_override,omitempty"`
+}
+
+// auditLog writes audit records as JSON lines to the sinks configured in the
+// audit section of the config file:
+//
+//	audit:
+//	  file: /var/log/go-vtm-cli/audit.log
+//	  syslog: true
+type auditLog struct {
+	writers []io.WriteCloser
+	profile string
+	url     string
+}
+
+// openAudit opens the configured audit sinks for changes to the current
+// connection. Failing to open them is fatal, since changes must not be made
+// without an audit trail.
+func openAudit() *auditLog {
+	return openAuditFor(viper.GetString("profile"), currentConnection().URL)
+}
+
+// openAuditFor is openAudit for changes to the vTM at url.
+func openAuditFor(profile, url string) *auditLog {
+	a := &auditLog{profile: profile, url: url}
+
+	if file := viper.GetString("audit.file"); file != "" {
+		path, err := homedir.Expand(file)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			log.Fatal("Cannot open audit log: ", err)
+		}
+		a.writers = append(a.writers, f)
+	}
+
+	if viper.GetBool("audit.syslog") {
+		w, err := openSyslog()
+		if err != nil {
+			log.Fatal("Cannot open syslog for auditing: ", err)
+		}
+		a.writers = append(a.writers, w)
+	}
+
+	return a
+}
+
+// change logs the result of applying c.
+func (a *auditLog) change(c *change, err error) {
+	r := a.record("set", c.Kind+" "+c.Name, err)
+	r.Field, r.Before, r.After = c.Field, c.Before, c.After
+
+	a.write(r)
+}
+
+// action logs an operation that is not a field change, e.g. a backup restore.
+func (a *auditLog) action(action, object string, err error) {
+	a.write(a.record(action, object, err))
+}
+
+func (a *auditLog) write(r *auditRecord) {
+	if len(a.writers) == 0 {
+		return
+	}
+
+	line, err := json.Marshal(r)
+	if err != nil {
+		log.Fatal(err)
+	}
+	line = append(line, '\n')
+
+	for _, w := range a.writers {
+		if _, err := w.Write(line); err != nil {
+			fmt.Fprintln(os.Stderr, "Warning: could not write audit log:", err)
+		}
+	}
+}
+
+func (a *auditLog) Close() {
+	for _, w := range a.writers {
+		w.Close()
+	}
+}
+
+func (a *auditLog) record(action, object string, result error) *auditRecord {
+	r := &auditRecord{
+		Time:     time.Now(),
+		User:     os.Getenv("USER"),
+		SudoUser: os.Getenv("SUDO_USER"),
+		Profile:  a.profile,
+		URL:      a.url,
+		Command:  strings.Join(os.Args, " "),
+		Action:   action,
+		Object:   object,
+		Result:   "ok",
+		Freeze:   frozenBy,
+	}
+
+	if u, err := user.Current(); err == nil {
+		r.User = u.Username
+	}
+	if result != nil {
+		r.Result = result.Error()
+	}
+
+	return r
+}
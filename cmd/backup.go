@@ -0,0 +1,75 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+
+	"github.com/spf13/cobra"
+)
+
+// backupCmd represents the backup command
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "traffic manager backup subcommands",
+}
+
+// backupProperties is the configuration document of a full backup.
+type backupProperties struct {
+	Properties struct {
+		Backup struct {
+			Description string `json:"description"`
+			TimeStamp   int64  `json:"time_stamp,omitempty"`
+			Version     string `json:"version,omitempty"`
+		} `json:"backup"`
+	} `json:"properties"`
+}
+
+func backupPath(name string) string {
+	return "config/active/backups/full/" + url.PathEscape(name)
+}
+
+// verifyArchive reads the whole backup archive at path and returns the number
+// of files in it.
+func verifyArchive(path string) (int, error) {
+	files := 0
+	err := walkArchive(path, func(name string, r io.Reader) error {
+		files++
+		_, err := io.Copy(ioutil.Discard, r)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %v", path, err)
+	}
+
+	if files == 0 {
+		return 0, fmt.Errorf("%s: archive is empty", path)
+	}
+
+	return files, nil
+}
+
+func init() {
+	RootCmd.AddCommand(backupCmd)
+}
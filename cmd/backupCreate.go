@@ -0,0 +1,80 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+var backupDescription string
+
+// backupCreateCmd represents the backup create command
+var backupCreateCmd = &cobra.Command{
+	Use:   "create [name]",
+	Short: "Create a full backup [name] on the traffic manager",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("Missing argument(s)")
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		backupCreate(args[0])
+	},
+}
+
+func backupCreate(name string) {
+	if dryRun {
+		fmt.Println(dryRunC)
+	}
+
+	client := initAPIClient()
+	enforceWrite()
+
+	fmt.Println("Creating backup", name, "on", client.conn.URL)
+	if dryRun {
+		return
+	}
+
+	var props backupProperties
+	props.Properties.Backup.Description = backupDescription
+
+	audit := openAudit()
+	defer audit.Close()
+
+	err := client.putJSON(backupPath(name), props)
+	audit.action("backup create", "backup "+name, err)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(name, ":\tcreated\n")
+}
+
+func init() {
+	backupCmd.AddCommand(backupCreateCmd)
+
+	backupCreateCmd.Flags().StringVar(&backupDescription, "description", "", "Description stored with the backup.")
+}
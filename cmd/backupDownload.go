@@ -0,0 +1,105 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// backupDownloadCmd represents the backup download command
+var backupDownloadCmd = &cobra.Command{
+	Use:   "download [name] [file]",
+	Short: "Download full backup [name] to [file]",
+	Long: `Download full backup [name] to [file].
+
+The archive is written to a temporary file next to [file] and only renamed
+once its size matches the response and it could be read back completely.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 2 {
+			return errors.New("Missing argument(s)")
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		backupDownload(args[0], args[1])
+	},
+}
+
+func backupDownload(name, file string) {
+	client := initAPIClient()
+
+	fmt.Println("Downloading backup", name, "from", client.conn.URL)
+	req, err := client.newRequest("GET", backupPath(name), nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	req.Header.Set("Accept", "application/x-tar")
+
+	resp, err := client.send(req)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer resp.Body.Close()
+	fmt.Println("Response:", resp.Status)
+
+	tmp := file + ".part"
+	f, err := os.Create(tmp)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(f, hash), resp.Body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && resp.ContentLength >= 0 && size != resp.ContentLength {
+		err = fmt.Errorf("received %d of %d bytes", size, resp.ContentLength)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		log.Fatal(err)
+	}
+
+	files, err := verifyArchive(tmp)
+	if err != nil {
+		os.Remove(tmp)
+		log.Fatal(err)
+	}
+
+	if err := os.Rename(tmp, file); err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Print(file, ":\t", size, " bytes, ", files, " files, sha256 ", fmt.Sprintf("%x", hash.Sum(nil)), "\n")
+}
+
+func init() {
+	backupCmd.AddCommand(backupDownloadCmd)
+}
@@ -0,0 +1,78 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// backupListCmd represents the backup list command
+var backupListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List full backups stored on the traffic manager",
+	Run: func(cmd *cobra.Command, args []string) {
+		backupList()
+	},
+}
+
+func backupList() {
+	client := initAPIClient()
+
+	fmt.Println("Getting backup list from", client.conn.URL)
+	names, err := client.children("config/active/backups/full")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 5, 2, ' ', 0)
+
+	for _, name := range names {
+		var props backupProperties
+		if err := client.getJSON(backupPath(name), &props); err != nil {
+			log.Fatal(err)
+		}
+		backup := props.Properties.Backup
+
+		created := "-"
+		if backup.TimeStamp > 0 {
+			created = time.Unix(backup.TimeStamp, 0).Format("2006-01-02 15:04:05")
+		}
+
+		fmt.Fprint(w, name, ":\t", created, "\t", backup.Version, "\t", backup.Description, "\n")
+	}
+
+	if len(names) == 0 {
+		fmt.Fprint(w, "(no backups)\n")
+	}
+
+	w.Flush()
+}
+
+func init() {
+	backupCmd.AddCommand(backupListCmd)
+}
@@ -0,0 +1,97 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+var backupRestoreConfirm string
+
+// backupRestoreCmd represents the backup restore command
+var backupRestoreCmd = &cobra.Command{
+	Use:   "restore [name]",
+	Short: "Restore full backup [name], replacing the cluster configuration",
+	Long: `Restore full backup [name], replacing the whole configuration of the cluster.
+
+The backup name has to be typed in again when prompted, or passed with
+--confirm for unattended use.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("Missing argument(s)")
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		backupRestore(args[0])
+	},
+}
+
+func backupRestore(name string) {
+	if dryRun {
+		fmt.Println(dryRunC)
+	}
+
+	client := initAPIClient()
+	enforceWrite()
+
+	var props backupProperties
+	if err := client.getJSON(backupPath(name), &props); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(name, ":\t", props.Properties.Backup.Version, "\t", props.Properties.Backup.Description, "\n")
+
+	if dryRun {
+		fmt.Println("Would restore", name, "on", client.conn.URL)
+		return
+	}
+
+	confirm := backupRestoreConfirm
+	if confirm == "" {
+		confirm = prompt("This replaces the configuration of " + client.conn.URL + ". Type the backup name to continue: ")
+	}
+	if confirm != name {
+		log.Fatal("Confirmation does not match backup name, not restoring")
+	}
+
+	audit := openAudit()
+	defer audit.Close()
+
+	fmt.Println("Restoring backup", name, "on", client.conn.URL)
+	resp, err := client.do("PUT", backupPath(name)+"?restore", "", nil)
+	audit.action("backup restore", "backup "+name, err)
+	if err != nil {
+		log.Fatal(err)
+	}
+	resp.Body.Close()
+	fmt.Println("Response:", resp.Status)
+}
+
+func init() {
+	backupCmd.AddCommand(backupRestoreCmd)
+
+	backupRestoreCmd.Flags().StringVar(&backupRestoreConfirm, "confirm", "", "Backup name, confirming the restore without prompting.")
+}
@@ -0,0 +1,117 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var backupUploadName string
+
+// backupUploadCmd represents the backup upload command
+var backupUploadCmd = &cobra.Command{
+	Use:   "upload [file]",
+	Short: "Upload backup archive [file] to the traffic manager",
+	Long: `Upload backup archive [file] to the traffic manager.
+
+The backup is stored under the file name without extension unless --name is
+given. Uploading does not restore it; use backup restore for that.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("Missing argument(s)")
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		backupUpload(args[0])
+	},
+}
+
+func backupUpload(file string) {
+	if dryRun {
+		fmt.Println(dryRunC)
+	}
+
+	name := backupUploadName
+	if name == "" {
+		name = filepath.Base(file)
+		for _, ext := range []string{".tar.gz", ".tgz", ".tar"} {
+			if strings.HasSuffix(name, ext) {
+				name = strings.TrimSuffix(name, ext)
+				break
+			}
+		}
+	}
+
+	files, err := verifyArchive(file)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	client := initAPIClient()
+	enforceWrite()
+
+	fmt.Println("Uploading", file, "as backup", name, "to", client.conn.URL)
+	if dryRun {
+		fmt.Print(name, ":\t", files, " files (not uploaded)\n")
+		return
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	audit := openAudit()
+	defer audit.Close()
+
+	r := bufio.NewReader(f)
+	contentType := "application/x-tar"
+	if isGzip(r) {
+		contentType = "application/gzip"
+	}
+
+	resp, err := client.do("PUT", backupPath(name), contentType, r)
+	audit.action("backup upload", "backup "+name, err)
+	if err != nil {
+		log.Fatal(err)
+	}
+	resp.Body.Close()
+	fmt.Println("Response:", resp.Status)
+
+	fmt.Print(name, ":\t", files, " files uploaded\n")
+}
+
+func init() {
+	backupCmd.AddCommand(backupUploadCmd)
+
+	backupUploadCmd.Flags().StringVar(&backupUploadName, "name", "", "Name of the backup on the traffic manager.")
+}
@@ -0,0 +1,258 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/martinlindner/go-vtm"
+	"github.com/mgutz/ansi"
+)
+
+var (
+	canarySize      int
+	canaryPause     time.Duration
+	canaryMaxErrors float64
+	canaryMaxDrop   float64
+)
+
+// objectRef identifies a vTM object in rollouts.
+type objectRef struct {
+	kind, name string
+}
+
+func (o objectRef) String() string {
+	return o.kind + " " + o.name
+}
+
+// healthSample holds the connection and error counters of an object.
+type healthSample struct {
+	conns, errors int64
+	at            time.Time
+}
+
+// health is the traffic of an object between two samples.
+type health struct {
+	connRate   float64
+	errorRatio float64
+}
+
+func (h health) String() string {
+	return fmt.Sprintf("%.1f conn/s, %.2f%% errors", h.connRate, h.errorRatio*100)
+}
+
+// checkRollout rejects a canary rollout of changes to objects without health
+// statistics, before anything is confirmed or applied.
+func checkRollout(changes []change) {
+	for _, c := range changes {
+		if c.Kind != "vserver" && c.Kind != "pool" {
+			log.Fatalf("--canary needs vserver or pool changes, %s %s has no health statistics", c.Kind, c.Name)
+		}
+	}
+}
+
+// sampleHealth reads the counters used to judge the health of an object:
+// connection errors and failures of vservers, queue timeouts and node errors
+// and failures of pools.
+func sampleHealth(api *apiClient, o objectRef) (healthSample, error) {
+	switch o.kind {
+	case "vserver":
+		s, err := api.vserverStatistics(o.name)
+		return healthSample{s.TotalConn, s.ConnectionErrors + s.ConnectionFailures, time.Now()}, err
+	case "pool":
+		s, err := api.poolStatistics(o.name)
+		if err != nil {
+			return healthSample{}, err
+		}
+		sample := healthSample{s.TotalConn, s.QueueTimeouts, time.Now()}
+
+		nodes, err := api.poolNodes(o.name)
+		if err != nil {
+			return healthSample{}, err
+		}
+		for _, node := range nodes {
+			n, err := api.poolNodeStatistics(o.name, node)
+			if err != nil {
+				return healthSample{}, err
+			}
+			sample.errors += n.Errors + n.Failures
+		}
+
+		return sample, nil
+	}
+
+	return healthSample{}, fmt.Errorf("%s: no health statistics for this object type", o)
+}
+
+// observe waits for the canary pause and returns the traffic of each object
+// during that time.
+func observe(api *apiClient, objects []objectRef) (map[objectRef]health, error) {
+	start := make(map[objectRef]healthSample)
+	for _, o := range objects {
+		s, err := sampleHealth(api, o)
+		if err != nil {
+			return nil, err
+		}
+		start[o] = s
+	}
+
+	time.Sleep(canaryPause)
+
+	result := make(map[objectRef]health)
+	for _, o := range objects {
+		end, err := sampleHealth(api, o)
+		if err != nil {
+			return nil, err
+		}
+
+		var h health
+		conns := float64(end.conns - start[o].conns)
+		if seconds := end.at.Sub(start[o].at).Seconds(); seconds > 0 {
+			h.connRate = conns / seconds
+		}
+		if conns > 0 {
+			h.errorRatio = float64(end.errors-start[o].errors) / conns
+		}
+		result[o] = h
+	}
+
+	return result, nil
+}
+
+// objectBatches groups changes by object and splits them into batches of at
+// most size objects, keeping the order in which objects were matched.
+func objectBatches(changes []change, size int) [][]change {
+	var batches [][]change
+	batchOf := make(map[objectRef]int)
+	objects := 0
+
+	for _, c := range changes {
+		o := objectRef{c.Kind, c.Name}
+		if i, ok := batchOf[o]; ok {
+			batches[i] = append(batches[i], c)
+			continue
+		}
+
+		if len(batches) == 0 || objects == size {
+			batches = append(batches, nil)
+			objects = 0
+		}
+		objects++
+
+		batchOf[o] = len(batches) - 1
+		batches[len(batches)-1] = append(batches[len(batches)-1], c)
+	}
+
+	return batches
+}
+
+// rollout applies changes to --canary objects first and then to the rest in
+// batches of the same size. Each batch is observed for --pause before and
+// after the change. If an object's error ratio rises or its connection rate
+// drops beyond the limits, everything applied so far is reverted.
+func rollout(client *stingray.Client, changes []change, entry *journalEntry, audit *auditLog) {
+	api := initAPIClient()
+	batches := objectBatches(changes, canarySize)
+
+	for i, batch := range batches {
+		var objects []objectRef
+		var names []string
+		seen := make(map[objectRef]bool)
+		for _, c := range batch {
+			o := objectRef{c.Kind, c.Name}
+			if !seen[o] {
+				seen[o] = true
+				objects = append(objects, o)
+				names = append(names, o.String())
+			}
+		}
+
+		label := "Canary"
+		if i > 0 {
+			label = fmt.Sprintf("Batch %d/%d", i, len(batches)-1)
+		}
+		fmt.Println(label+":", strings.Join(names, ", "))
+
+		fmt.Println("Measuring baseline for", canaryPause)
+		before, err := observe(api, objects)
+		if err != nil {
+			abortRollout(client, entry, audit, err.Error())
+		}
+
+		if err := applyBatch(client, batch, entry, audit); err != nil {
+			abortRollout(client, entry, audit, err.Error())
+		}
+
+		fmt.Println("Applied, observing for", canaryPause)
+		after, err := observe(api, objects)
+		if err != nil {
+			abortRollout(client, entry, audit, err.Error())
+		}
+
+		if !healthy(objects, before, after) {
+			abortRollout(client, entry, audit, "Health check failed")
+		}
+	}
+
+	fmt.Println("Rollout complete")
+}
+
+// abortRollout reverts everything applied so far and exits with reason,
+// saying whether the revert succeeded.
+func abortRollout(client *stingray.Client, entry *journalEntry, audit *auditLog, reason string) {
+	if !revertEntry(client, entry, audit) {
+		log.Fatal(reason, ", not every change was reverted")
+	}
+	log.Fatal(reason, ", changes reverted")
+}
+
+// healthy prints the traffic of each object before and after the change and
+// reports whether it stayed within the limits.
+func healthy(objects []objectRef, before, after map[objectRef]health) bool {
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 5, 2, ' ', 0)
+	defer w.Flush()
+
+	ok := true
+	for _, o := range objects {
+		b, a := before[o], after[o]
+
+		state := "ok"
+		if a.errorRatio-b.errorRatio > canaryMaxErrors {
+			state = "error ratio increased"
+		} else if b.connRate > 0 && a.connRate < b.connRate*(1-canaryMaxDrop) {
+			state = "connection rate dropped"
+		}
+		if state != "ok" {
+			ok = false
+			state = ansi.Color(state, "red")
+		}
+
+		fmt.Fprint(w, o, ":\t", b, " -> ", a, "\t", state, "\n")
+	}
+
+	return ok
+}
@@ -0,0 +1,450 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/martinlindner/go-vtm"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// change is a modification of a single field of a vTM object. Write commands
+// collect their changes first and hand them to applyChanges, which takes care
+// of dry-run and the journal. Values are stored as JSON so changes can be
+// saved and replayed later, e.g. by undo.
+type change struct {
+	Kind   string          `json:"kind"`
+	Name   string          `json:"name"`
+	Field  string          `json:"field"`
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+
+	// object is the resource the change was computed from, if still at hand.
+	object stingray.Resourcer
+}
+
+// objectKind knows how to load one type of object and how to access the
+// fields write commands change. A field accessor returns a pointer to the
+// field, which is used to both marshal and unmarshal its value.
+type objectKind struct {
+	load   func(client *stingray.Client, name string) (stingray.Resourcer, error)
+	fields map[string]func(r stingray.Resourcer) interface{}
+}
+
+var objectKinds = map[string]objectKind{
+	"vserver": {
+		load: func(client *stingray.Client, name string) (stingray.Resourcer, error) {
+			r, _, err := client.GetVirtualServer(name)
+			return r, err
+		},
+		fields: map[string]func(r stingray.Resourcer) interface{}{
+			"basic.pool": func(r stingray.Resourcer) interface{} {
+				return &r.(*stingray.VirtualServer).Basic.Pool
+			},
+			"basic.request_rules": func(r stingray.Resourcer) interface{} {
+				return &r.(*stingray.VirtualServer).Basic.RequestRules
+			},
+			"connection.timeout": func(r stingray.Resourcer) interface{} {
+				return &r.(*stingray.VirtualServer).Connection.Timeout
+			},
+			"ssl.server_cert_default": func(r stingray.Resourcer) interface{} {
+				return &r.(*stingray.VirtualServer).SSL.ServerCertDefault
+			},
+			"ssl.server_cert_host_mapping": func(r stingray.Resourcer) interface{} {
+				return &r.(*stingray.VirtualServer).SSL.ServerCertHostMapping
+			},
+		},
+	},
+	"pool": {
+		load: func(client *stingray.Client, name string) (stingray.Resourcer, error) {
+			r, _, err := client.GetPool(name)
+			return r, err
+		},
+		fields: map[string]func(r stingray.Resourcer) interface{}{
+			"basic.nodes_table": func(r stingray.Resourcer) interface{} {
+				return &r.(*stingray.Pool).Basic.NodesTable
+			},
+			"connection.max_reply_time": func(r stingray.Resourcer) interface{} {
+				return &r.(*stingray.Pool).Connection.MaxReplyTime
+			},
+		},
+	},
+	"tipgroup": {
+		load: func(client *stingray.Client, name string) (stingray.Resourcer, error) {
+			r, _, err := client.GetTrafficIPGroup(name)
+			return r, err
+		},
+		fields: map[string]func(r stingray.Resourcer) interface{}{
+			"basic.enabled": func(r stingray.Resourcer) interface{} {
+				return &r.(*stingray.TrafficIPGroup).Basic.Enabled
+			},
+			"basic.slaves": func(r stingray.Resourcer) interface{} {
+				return &r.(*stingray.TrafficIPGroup).Basic.Slaves
+			},
+		},
+	},
+}
+
+// newChange records that field of object r should be set to value.
+func newChange(kind, name, field string, r stingray.Resourcer, value interface{}) change {
+	c := change{Kind: kind, Name: name, Field: field, object: r}
+
+	before, err := c.value(r)
+	if err != nil {
+		log.Fatal(err)
+	}
+	after, err := json.Marshal(value)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	c.Before, c.After = before, after
+
+	return c
+}
+
+func (c *change) String() string {
+	return c.Kind + " " + c.Name + " " + c.Field
+}
+
+// reverse returns the change that restores the previous value.
+func (c change) reverse() change {
+	c.Before, c.After = c.After, c.Before
+
+	return c
+}
+
+func (c *change) accessor() (objectKind, func(r stingray.Resourcer) interface{}, error) {
+	kind, ok := objectKinds[c.Kind]
+	if !ok {
+		return kind, nil, fmt.Errorf("%s: unknown object type", c)
+	}
+
+	field, ok := kind.fields[c.Field]
+	if !ok {
+		return kind, nil, fmt.Errorf("%s: unknown field", c)
+	}
+
+	return kind, field, nil
+}
+
+// load returns the object the change applies to, fetching it if necessary.
+func (c *change) load(client *stingray.Client) (stingray.Resourcer, error) {
+	if c.object != nil {
+		return c.object, nil
+	}
+
+	kind, _, err := c.accessor()
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := kind.load(client, c.Name)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", c, err)
+	}
+	c.object = r
+
+	return r, nil
+}
+
+// value returns the JSON encoded value of the changed field of r.
+func (c *change) value(r stingray.Resourcer) (json.RawMessage, error) {
+	_, field, err := c.accessor()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(field(r))
+}
+
+// shareObjects sets the object of each change to the one load returns for
+// the first change of the same object. All changes of an object then modify
+// and save the same copy; with a copy each, saving a later change would
+// discard the earlier ones.
+func shareObjects(changes []change, load func(c *change) (stingray.Resourcer, error)) error {
+	objects := make(map[objectRef]stingray.Resourcer)
+
+	for i := range changes {
+		c := &changes[i]
+		o := objectRef{c.Kind, c.Name}
+
+		if r, ok := objects[o]; ok {
+			c.object = r
+			continue
+		}
+
+		r, err := load(c)
+		if err != nil {
+			return err
+		}
+		c.object = r
+		objects[o] = r
+	}
+
+	return nil
+}
+
+// reload fetches the current state of the objects of changes, once per
+// object.
+func reload(client *stingray.Client, changes []change) error {
+	return shareObjects(changes, func(c *change) (stingray.Resourcer, error) {
+		c.object = nil
+		return c.load(client)
+	})
+}
+
+// check reports whether the field of the loaded object holds want. It
+// returns the current value for error messages. Use reload first to check
+// against the current state.
+func (c *change) check(client *stingray.Client, want json.RawMessage) (bool, json.RawMessage, error) {
+	r, err := c.load(client)
+	if err != nil {
+		return false, nil, err
+	}
+
+	current, err := c.value(r)
+	if err != nil {
+		return false, nil, err
+	}
+
+	return jsonEqual(current, want), current, nil
+}
+
+// checkInOrder checks that the field of each change holds its previous
+// value, as it will once the changes before it are applied, and sets the new
+// value on the loaded object. ok is called for each matching change. The
+// fields holding something else are returned. Use reload first.
+func checkInOrder(client *stingray.Client, changes []change, ok func(c *change)) ([]string, error) {
+	var conflicts []string
+
+	for i := range changes {
+		c := &changes[i]
+
+		match, current, err := c.check(client, c.Before)
+		if err != nil {
+			return nil, err
+		}
+		if !match {
+			conflicts = append(conflicts, fmt.Sprintf("%s is %s, expected %s", c, current, c.Before))
+			continue
+		}
+
+		if err := c.set(); err != nil {
+			return nil, err
+		}
+		if ok != nil {
+			ok(c)
+		}
+	}
+
+	return conflicts, nil
+}
+
+// set sets the field of the loaded object to the new value without saving
+// it.
+func (c *change) set() error {
+	_, field, err := c.accessor()
+	if err != nil {
+		return err
+	}
+
+	if c.object == nil {
+		return fmt.Errorf("%s: object not loaded", c)
+	}
+
+	if err := json.Unmarshal(c.After, field(c.object)); err != nil {
+		return fmt.Errorf("%s: %v", c, err)
+	}
+
+	return nil
+}
+
+// apply sets the field to the new value and saves the object.
+func (c *change) apply(client *stingray.Client) error {
+	r, err := c.load(client)
+	if err != nil {
+		return err
+	}
+
+	if err := c.set(); err != nil {
+		return err
+	}
+
+	if _, err := client.Set(r); err != nil {
+		return fmt.Errorf("%s: %v", c, err)
+	}
+
+	return nil
+}
+
+func jsonEqual(a, b json.RawMessage) bool {
+	var bufA, bufB bytes.Buffer
+	if json.Compact(&bufA, a) != nil || json.Compact(&bufB, b) != nil {
+		return bytes.Equal(a, b)
+	}
+
+	return bytes.Equal(bufA.Bytes(), bufB.Bytes())
+}
+
+// applyChanges sends changes to the traffic manager and records the applied
+// ones in the journal and the audit log. The changes are checked against the
+// policy first, in dry-run mode as well. Interactive sessions are asked for
+// confirmation before anything is sent. With --at the changes are applied
+// later, see scheduleChanges. It reports whether the changes are in effect
+// when it returns: applied now, and not reverted by --for.
+func applyChanges(client *stingray.Client, changes []change) bool {
+	return commitChanges(client, changes, newJournalEntry())
+}
+
+// addChangeFlags registers the flags controlling when and how a write
+// command applies its changes.
+func addChangeFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&scheduleAt, "at", "", "Apply the changes at this time, e.g. 2017-11-01T02:00, if the objects are unchanged by then.")
+	cmd.Flags().BoolVar(&scheduleQueue, "queue", false, "With --at, queue the changes for the scheduler command instead of waiting.")
+	cmd.Flags().DurationVar(&timebox, "for", 0, "Revert the changes after this time, e.g. 30m. Stays in the foreground until then.")
+	cmd.Flags().IntVar(&canarySize, "canary", 0, "Roll out changes to this many objects at a time, checking their health in between.")
+	cmd.Flags().DurationVar(&canaryPause, "pause", time.Minute, "Time to observe each canary batch before and after the change.")
+	cmd.Flags().Float64Var(&canaryMaxErrors, "canary-max-errors", 0.01, "Maximum increase of the error ratio (errors per connection) during a canary rollout.")
+	cmd.Flags().Float64Var(&canaryMaxDrop, "canary-max-drop", 0.5, "Maximum relative drop of the connection rate during a canary rollout.")
+}
+
+// commitChanges is applyChanges with a prepared journal entry.
+func commitChanges(client *stingray.Client, changes []change, entry *journalEntry) bool {
+	if len(changes) == 0 {
+		return false
+	}
+
+	enforcePolicy(changes)
+	if canarySize > 0 {
+		checkRollout(changes)
+	}
+
+	if dryRun {
+		return false
+	}
+
+	if !confirmChanges(changes) {
+		log.Fatal("Aborted, nothing changed")
+	}
+
+	if scheduleAt != "" && !scheduleChanges(client, changes) {
+		return false
+	}
+
+	audit := openAuditFor(viper.GetString("profile"), entry.URL)
+	defer audit.Close()
+
+	if canarySize > 0 {
+		rollout(client, changes, entry, audit)
+	} else if err := applyBatch(client, changes, entry, audit); err != nil {
+		log.Fatal(err)
+	}
+
+	entry.save()
+
+	if timebox > 0 {
+		timeboxChanges(client, entry, audit)
+		return false
+	}
+
+	return true
+}
+
+// applyBatch applies changes in order and adds them to entry and the audit
+// log. The entry is saved if a change fails.
+func applyBatch(client *stingray.Client, changes []change, entry *journalEntry, audit *auditLog) error {
+	for i := range changes {
+		err := changes[i].apply(client)
+		audit.change(&changes[i], err)
+		if err != nil {
+			entry.save()
+			return err
+		}
+		entry.Changes = append(entry.Changes, changes[i])
+	}
+
+	return nil
+}
+
+// revertEntry restores the previous values of all changes of entry, newest
+// first, and records the reverts in the journal as an undo of entry. Objects
+// that no longer hold the value set by entry are left alone. It returns true
+// if every change is reverted, so an interrupted revert can be retried.
+func revertEntry(client *stingray.Client, entry *journalEntry, audit *auditLog) bool {
+	entry.save()
+
+	undoEntry := newJournalEntryFor(entry.URL)
+	undoEntry.UndoOf = entry.ID
+
+	var reverts []change
+	for i := len(entry.Changes) - 1; i >= 0; i-- {
+		reverts = append(reverts, entry.Changes[i].reverse())
+	}
+
+	if err := reload(client, reverts); err != nil {
+		fmt.Fprintln(os.Stderr, "Revert failed:", err)
+		return false
+	}
+
+	reverted := true
+	for i := range reverts {
+		c := &reverts[i]
+
+		ok, current, err := c.check(client, c.Before)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Revert failed:", err)
+			reverted = false
+			continue
+		}
+		if !ok && jsonEqual(current, c.After) {
+			// Reverted by an earlier, interrupted run.
+			continue
+		}
+		if !ok {
+			fmt.Print(c.Kind, " ", c.Name, ":\t", c.Field, " is ", string(current), ", changed by someone else, not reverting\n")
+			reverted = false
+			continue
+		}
+
+		err = c.apply(client)
+		audit.change(c, err)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Revert failed:", err)
+			reverted = false
+			continue
+		}
+
+		fmt.Print(c.Kind, " ", c.Name, ":\t", c.Field, " ", string(c.Before), " -> ", string(c.After), " (reverted)\n")
+		undoEntry.Changes = append(undoEntry.Changes, *c)
+	}
+
+	undoEntry.save()
+
+	return reverted
+}
@@ -0,0 +1,126 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/martinlindner/go-vtm"
+)
+
+// sslVirtualServer returns a vserver using defaultCert by default and
+// hostCert for www.example.com.
+func sslVirtualServer(defaultCert, hostCert string) *stingray.VirtualServer {
+	host := "www.example.com"
+	r := stingray.NewVirtualServer("www")
+	r.SSL.ServerCertDefault = &defaultCert
+	r.SSL.ServerCertHostMapping = &stingray.ServerCertHostMappingTable{{Host: &host, Certificate: &hostCert}}
+
+	return r
+}
+
+func TestUndoTwoFieldsOfOneObject(t *testing.T) {
+	r := sslVirtualServer("old", "old")
+	host := "www.example.com"
+	newCert := "new"
+	changes := []change{
+		newChange("vserver", "www", "ssl.server_cert_default", r, "new"),
+		newChange("vserver", "www", "ssl.server_cert_host_mapping", r,
+			stingray.ServerCertHostMappingTable{{Host: &host, Certificate: &newCert}}),
+	}
+
+	// As read back from the journal.
+	data, err := json.Marshal(changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var journaled []change
+	if err := json.Unmarshal(data, &journaled); err != nil {
+		t.Fatal(err)
+	}
+
+	var reverts []change
+	for i := len(journaled) - 1; i >= 0; i-- {
+		reverts = append(reverts, journaled[i].reverse())
+	}
+
+	loads := 0
+	err = shareObjects(reverts, func(c *change) (stingray.Resourcer, error) {
+		loads++
+		return sslVirtualServer("new", "new"), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loads != 1 {
+		t.Errorf("object loaded %d times, want 1", loads)
+	}
+
+	conflicts, err := checkInOrder(nil, reverts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conflicts) > 0 {
+		t.Fatal("unexpected conflicts:", conflicts)
+	}
+
+	// Each change saves its object, the last save has to hold both.
+	saved := reverts[len(reverts)-1].object.(*stingray.VirtualServer)
+	if got := *saved.SSL.ServerCertDefault; got != "old" {
+		t.Errorf("default certificate is %q, want old", got)
+	}
+	if got := *(*saved.SSL.ServerCertHostMapping)[0].Certificate; got != "old" {
+		t.Errorf("host certificate is %q, want old", got)
+	}
+}
+
+func TestCheckInOrderSameField(t *testing.T) {
+	r := sslVirtualServer("a", "a")
+	first := newChange("vserver", "www", "ssl.server_cert_default", r, "b")
+	second := change{Kind: "vserver", Name: "www", Field: "ssl.server_cert_default",
+		Before: json.RawMessage(`"b"`), After: json.RawMessage(`"c"`)}
+	changes := []change{first, second}
+
+	err := shareObjects(changes, func(c *change) (stingray.Resourcer, error) {
+		return sslVirtualServer("a", "a"), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conflicts, err := checkInOrder(nil, changes, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conflicts) > 0 {
+		t.Fatal("chained changes conflict:", conflicts)
+	}
+
+	changes[1].Before = json.RawMessage(`"x"`)
+	changes[0].object, changes[1].object = sslVirtualServer("a", "a"), nil
+	if err := shareObjects(changes, func(c *change) (stingray.Resourcer, error) { return c.object, nil }); err != nil {
+		t.Fatal(err)
+	}
+	if conflicts, _ := checkInOrder(nil, changes, nil); len(conflicts) != 1 {
+		t.Errorf("got %d conflicts, want 1", len(conflicts))
+	}
+}
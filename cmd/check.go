@@ -0,0 +1,135 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// checkCmd represents the check command
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Monitoring plugin checks",
+	Long: `Checks for Nagios, Icinga and other monitoring systems running plugins. Each
+check prints one line with performance data and exits with 0 (OK),
+1 (WARNING), 2 (CRITICAL) or 3 (UNKNOWN). A check is WARNING or CRITICAL if
+its value is above --warning or --critical.`,
+}
+
+const (
+	checkOK = iota
+	checkWarning
+	checkCritical
+	checkUnknown
+)
+
+var checkStates = []string{"OK", "WARNING", "CRITICAL", "UNKNOWN"}
+
+// osExit is os.Exit, replaced in tests.
+var osExit = os.Exit
+
+// checkRunning is set by Execute when a check command runs, which prints
+// nothing but the plugin output on stdout.
+var checkRunning bool
+
+// checkResult is the outcome of a check.
+type checkResult struct {
+	name     string
+	state    int
+	text     []string
+	perfdata []string
+}
+
+// raise sets the state to state if that is worse than the current one.
+func (r *checkResult) raise(state int) {
+	if state > r.state {
+		r.state = state
+	}
+}
+
+// threshold raises the state according to value.
+func (r *checkResult) threshold(value, warning, critical float64) {
+	switch {
+	case value > critical:
+		r.raise(checkCritical)
+	case value > warning:
+		r.raise(checkWarning)
+	}
+}
+
+// perf adds performance data in the form 'label'=value[uom];warn;crit;min;max.
+// Thresholds are left out if negative.
+func (r *checkResult) perf(label string, value float64, uom string, warning, critical, min, max float64) {
+	format := func(v float64) string {
+		if v < 0 {
+			return ""
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	label = strings.Replace(label, "'", "''", -1)
+	r.perfdata = append(r.perfdata, fmt.Sprintf("'%s'=%s%s;%s;%s;%s;%s", label,
+		strconv.FormatFloat(value, 'f', -1, 64), uom, format(warning), format(critical), format(min), format(max)))
+}
+
+// exit prints the plugin output and exits with the state.
+func (r *checkResult) exit() {
+	line := r.name + " " + checkStates[r.state] + " - " + strings.Join(r.text, ", ")
+	if len(r.perfdata) > 0 {
+		line += " | " + strings.Join(r.perfdata, " ")
+	}
+
+	fmt.Println(line)
+	osExit(r.state)
+}
+
+// checkFailed reports a check that could not be run as UNKNOWN.
+func checkFailed(name string, err error) {
+	r := checkResult{name: name, state: checkUnknown, text: []string{err.Error()}}
+	r.exit()
+}
+
+// checkError reports err, which ended the check cmd before it had a result,
+// as UNKNOWN.
+func checkError(cmd *cobra.Command, err error) {
+	checkFailed(strings.ToUpper(cmd.Name()), err)
+}
+
+// isCheck reports whether cmd is the check command or one of its
+// subcommands.
+func isCheck(cmd *cobra.Command) bool {
+	for ; cmd != nil; cmd = cmd.Parent() {
+		if cmd == checkCmd {
+			return true
+		}
+	}
+
+	return false
+}
+
+func init() {
+	RootCmd.AddCommand(checkCmd)
+}
@@ -0,0 +1,103 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	checkClusterWarning  float64
+	checkClusterCritical float64
+)
+
+// checkClusterCmd represents the check cluster command
+var checkClusterCmd = &cobra.Command{
+	Use:   "cluster",
+	Short: "Check the traffic managers and failed nodes of the cluster",
+	Long: `Check the state of each traffic manager in the cluster and the number of
+failed pool nodes. A traffic manager that is unreachable or reports errors is
+CRITICAL, one that reports warnings is WARNING. --warning and --critical
+apply to the number of failed nodes.`,
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := checkCluster()
+		if err != nil {
+			return err
+		}
+
+		r.exit()
+		return nil
+	},
+}
+
+func checkCluster() (checkResult, error) {
+	const name = "CLUSTER"
+
+	api, err := connectAPI()
+	if err != nil {
+		return checkResult{}, err
+	}
+	s, err := clusterHealth(api)
+	if err != nil {
+		return checkResult{}, err
+	}
+
+	r := checkResult{name: name}
+	ok := 0
+
+	for _, m := range s.Members {
+		switch m.State {
+		case "ok":
+			ok++
+			continue
+		case "warn":
+			r.raise(checkWarning)
+		default:
+			r.raise(checkCritical)
+		}
+		r.text = append(r.text, m.Name+" "+m.State)
+	}
+
+	failed := float64(len(s.FailedNodes))
+	r.threshold(failed, checkClusterWarning, checkClusterCritical)
+
+	r.text = append([]string{
+		fmt.Sprintf("%d of %d traffic managers ok, %d failed nodes", ok, len(s.Members), len(s.FailedNodes)),
+	}, r.text...)
+	r.perf("members", float64(len(s.Members)), "", -1, -1, 0, -1)
+	r.perf("members_ok", float64(ok), "", -1, -1, 0, float64(len(s.Members)))
+	r.perf("failed_nodes", failed, "", checkClusterWarning, checkClusterCritical, 0, -1)
+	r.perf("errors", float64(len(s.Errors)), "", -1, -1, 0, -1)
+	r.perf("warnings", float64(len(s.Warnings)), "", -1, -1, 0, -1)
+
+	return r, nil
+}
+
+func init() {
+	checkCmd.AddCommand(checkClusterCmd)
+
+	checkClusterCmd.Flags().Float64VarP(&checkClusterWarning, "warning", "w", 0, "Warning above this number of failed nodes.")
+	checkClusterCmd.Flags().Float64VarP(&checkClusterCritical, "critical", "c", 2, "Critical above this number of failed nodes.")
+}
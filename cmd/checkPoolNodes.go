@@ -0,0 +1,133 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/gobwas/glob"
+	"github.com/spf13/cobra"
+)
+
+var (
+	checkPoolNodesWarning  float64
+	checkPoolNodesCritical float64
+)
+
+// checkPoolNodesCmd represents the check pool-nodes command
+var checkPoolNodesCmd = &cobra.Command{
+	Use:   "pool-nodes [pool]",
+	Short: "Check the percentage of nodes that are not alive in [pool] (default all)",
+	Long: `Check the percentage of nodes that are not alive in each pool matching [pool]
+(default all). Disabled nodes are not counted. The worst pool decides the
+state.`,
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target := "*"
+		if len(args) > 0 {
+			target = args[0]
+		}
+
+		r, err := checkPoolNodes(target)
+		if err != nil {
+			return err
+		}
+
+		r.exit()
+		return nil
+	},
+}
+
+func checkPoolNodes(targetPool string) (checkResult, error) {
+	const name = "POOL-NODES"
+
+	poolGlob, err := glob.Compile(targetPool)
+	if err != nil {
+		return checkResult{}, err
+	}
+
+	api, err := connectAPI()
+	if err != nil {
+		return checkResult{}, err
+	}
+	pools, err := api.children("status/local_tm/statistics/pools")
+	if err != nil {
+		return checkResult{}, err
+	}
+
+	r := checkResult{name: name}
+	total, down := 0, 0
+
+	for _, pool := range pools {
+		if !poolGlob.Match(pool) {
+			continue
+		}
+
+		table, err := api.poolNodesTable(pool)
+		if err != nil {
+			return checkResult{}, err
+		}
+
+		nodes, poolDown := 0, 0
+		for _, n := range table {
+			if n.State == "disabled" {
+				continue
+			}
+
+			s, err := api.poolNodeStatistics(pool, n.Node)
+			if err != nil {
+				return checkResult{}, err
+			}
+
+			nodes++
+			if s.State != "alive" {
+				poolDown++
+				r.text = append(r.text, fmt.Sprintf("%s %s %s", pool, n.Node, s.State))
+			}
+		}
+		if nodes == 0 {
+			continue
+		}
+
+		percent := float64(poolDown) * 100 / float64(nodes)
+		r.threshold(percent, checkPoolNodesWarning, checkPoolNodesCritical)
+		r.perf(pool, percent, "%", checkPoolNodesWarning, checkPoolNodesCritical, 0, 100)
+
+		total += nodes
+		down += poolDown
+	}
+
+	if total == 0 {
+		return checkResult{}, fmt.Errorf("No pool with nodes matches %s", targetPool)
+	}
+
+	r.text = append([]string{fmt.Sprintf("%d of %d nodes not alive", down, total)}, r.text...)
+
+	return r, nil
+}
+
+func init() {
+	checkCmd.AddCommand(checkPoolNodesCmd)
+
+	checkPoolNodesCmd.Flags().Float64VarP(&checkPoolNodesWarning, "warning", "w", 0, "Warning above this percentage of nodes not alive in a pool.")
+	checkPoolNodesCmd.Flags().Float64VarP(&checkPoolNodesCritical, "critical", "c", 50, "Critical above this percentage of nodes not alive in a pool.")
+}
@@ -0,0 +1,142 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gobwas/glob"
+	"github.com/spf13/cobra"
+)
+
+var (
+	checkVserverErrorsWarning  float64
+	checkVserverErrorsCritical float64
+	checkVserverErrorsInterval time.Duration
+)
+
+// checkVserverErrorsCmd represents the check vserver-errors command
+var checkVserverErrorsCmd = &cobra.Command{
+	Use:   "vserver-errors [vserver]",
+	Short: "Check the error percentage of [vserver] (default all)",
+	Long: `Check the percentage of connections with errors or failures of each vserver
+matching [vserver] (default all), measured over --interval. The worst vserver
+decides the state.`,
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target := "*"
+		if len(args) > 0 {
+			target = args[0]
+		}
+
+		r, err := checkVserverErrors(target)
+		if err != nil {
+			return err
+		}
+
+		r.exit()
+		return nil
+	},
+}
+
+func checkVserverErrors(targetVserver string) (checkResult, error) {
+	const name = "VSERVER-ERRORS"
+
+	vserverGlob, err := glob.Compile(targetVserver)
+	if err != nil {
+		return checkResult{}, err
+	}
+
+	api, err := connectAPI()
+	if err != nil {
+		return checkResult{}, err
+	}
+	vservers, err := api.children("status/local_tm/statistics/virtual_servers")
+	if err != nil {
+		return checkResult{}, err
+	}
+
+	var names []string
+	for _, vserver := range vservers {
+		if vserverGlob.Match(vserver) {
+			names = append(names, vserver)
+		}
+	}
+	if len(names) == 0 {
+		return checkResult{}, fmt.Errorf("No vserver matches %s", targetVserver)
+	}
+
+	sample := func() (map[string]vserverStatistics, error) {
+		stats := make(map[string]vserverStatistics)
+		for _, vserver := range names {
+			s, err := api.vserverStatistics(vserver)
+			if err != nil {
+				return nil, err
+			}
+			stats[vserver] = s
+		}
+		return stats, nil
+	}
+
+	before, err := sample()
+	if err != nil {
+		return checkResult{}, err
+	}
+	time.Sleep(checkVserverErrorsInterval)
+	after, err := sample()
+	if err != nil {
+		return checkResult{}, err
+	}
+
+	r := checkResult{name: name}
+	worst, worstPercent := "", -1.0
+
+	for _, vserver := range names {
+		a, b := before[vserver], after[vserver]
+		conns := b.TotalConn - a.TotalConn
+		errors := b.ConnectionErrors + b.ConnectionFailures - a.ConnectionErrors - a.ConnectionFailures
+
+		percent := 0.0
+		if conns > 0 {
+			percent = float64(errors) * 100 / float64(conns)
+		}
+		if percent > worstPercent {
+			worst, worstPercent = vserver, percent
+		}
+
+		r.threshold(percent, checkVserverErrorsWarning, checkVserverErrorsCritical)
+		r.perf(vserver, percent, "%", checkVserverErrorsWarning, checkVserverErrorsCritical, 0, 100)
+	}
+
+	r.text = append(r.text, fmt.Sprintf("%s %.2f%% errors over %s", worst, worstPercent, checkVserverErrorsInterval))
+
+	return r, nil
+}
+
+func init() {
+	checkCmd.AddCommand(checkVserverErrorsCmd)
+
+	checkVserverErrorsCmd.Flags().Float64VarP(&checkVserverErrorsWarning, "warning", "w", 1, "Warning above this percentage of connections with errors.")
+	checkVserverErrorsCmd.Flags().Float64VarP(&checkVserverErrorsCritical, "critical", "c", 5, "Critical above this percentage of connections with errors.")
+	checkVserverErrorsCmd.Flags().DurationVar(&checkVserverErrorsInterval, "interval", 10*time.Second, "Time to measure errors over.")
+}
@@ -0,0 +1,101 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// runCheck runs f with stdout captured and returns the output and the exit
+// code.
+func runCheck(t *testing.T, f func()) (string, int) {
+	code := -1
+	osExit = func(c int) {
+		if code < 0 {
+			code = c
+		}
+	}
+	defer func() { osExit = os.Exit }()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	f()
+	os.Stdout = stdout
+	w.Close()
+
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return strings.TrimSpace(string(out)), code
+}
+
+func TestCheckExitCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func()
+		code int
+		line string
+	}{
+		{"ok", func() {
+			r := checkResult{name: "CLUSTER", text: []string{"2 traffic managers ok"}}
+			r.exit()
+		}, 0, "CLUSTER OK - 2 traffic managers ok"},
+		{"critical", func() {
+			r := checkResult{name: "CLUSTER"}
+			r.threshold(5, 1, 2)
+			r.text = []string{"5 failed nodes"}
+			r.exit()
+		}, 2, "CLUSTER CRITICAL - 5 failed nodes"},
+		{"check failed", func() {
+			checkFailed("POOL-NODES", errors.New("No pool with nodes matches web"))
+		}, 3, "POOL-NODES UNKNOWN - No pool with nodes matches web"},
+		{"error from check", func() {
+			viper.Set("profile", "staging")
+			defer viper.Set("profile", "")
+
+			if _, err := checkPoolNodes("*"); err != nil {
+				checkError(checkPoolNodesCmd, err)
+			}
+		}, 3, "POOL-NODES UNKNOWN - Unknown profile: staging"},
+	}
+
+	for _, test := range tests {
+		line, code := runCheck(t, test.run)
+		if code != test.code {
+			t.Errorf("%s: exit code %d, want %d", test.name, code, test.code)
+		}
+		if line != test.line {
+			t.Errorf("%s: got %q, want %q", test.name, line, test.line)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// confFile holds the settings of one object in the traffic manager's on-disk
+// configuration format, as found below zxtm/conf in backups: one
+// "key value" pair per line, '#' comments, and continuation lines starting
+// with whitespace for multi-line values. Values are kept verbatim, quotes
+// included.
+type confFile map[string]string
+
+func parseConfFile(r io.Reader) (confFile, error) {
+	conf := make(confFile)
+	lastKey := ""
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if (line[0] == ' ' || line[0] == '\t') && lastKey != "" {
+			conf[lastKey] += "\n" + strings.TrimSpace(line)
+			continue
+		}
+
+		key, value := line, ""
+		if i := strings.IndexAny(line, " \t"); i >= 0 {
+			key, value = line[:i], strings.TrimSpace(line[i+1:])
+		}
+
+		conf[key] = value
+		lastKey = key
+	}
+
+	return conf, scanner.Err()
+}
+
+// list returns a space separated value as a slice.
+func (c confFile) list(key string) []string {
+	return strings.Fields(c[key])
+}
+
+// int returns the numeric value of key, or def if it is unset or invalid.
+func (c confFile) int(key string, def int) int {
+	v, ok := c[key]
+	if !ok {
+		return def
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil {
+		return def
+	}
+
+	return n
+}
+
+// bool returns the value of a yes/no setting, or def if it is unset.
+func (c confFile) bool(key string, def bool) bool {
+	switch strings.ToLower(strings.TrimSpace(c[key])) {
+	case "yes", "true", "1":
+		return true
+	case "no", "false", "0":
+		return false
+	}
+
+	return def
+}
@@ -0,0 +1,77 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseConfFile(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  confFile
+	}{
+		{"key value", "enabled yes\nport 443\n", confFile{"enabled": "yes", "port": "443"}},
+		{"tab separated", "pool\tweb\n", confFile{"pool": "web"}},
+		{"spaces in value", "note  web tier, new  \n", confFile{"note": "web tier, new"}},
+		{"quoted value", "note \"web tier\"\n", confFile{"note": "\"web tier\""}},
+		{"quoted with hash", "note \"# not a comment\"\n", confFile{"note": "\"# not a comment\""}},
+		{"no value", "request_rules\n", confFile{"request_rules": ""}},
+		{"comments and blank lines", "# comment\n\nport 80\n   \n", confFile{"port": "80"}},
+		{"multi-line", "nodes 10.0.0.1:80\n\t10.0.0.2:80\n  10.0.0.3:80\nport 80\n",
+			confFile{"nodes": "10.0.0.1:80\n10.0.0.2:80\n10.0.0.3:80", "port": "80"}},
+		{"multi-line without first value", "request_rules\n\tredirect\n\t\"add header\"\n",
+			confFile{"request_rules": "\nredirect\n\"add header\""}},
+		{"multi-line after comment", "nodes a:80\n# comment\n\tb:80\n", confFile{"nodes": "a:80\nb:80"}},
+		{"windows line endings", "port 80\r\n", confFile{"port": "80"}},
+	}
+
+	for _, test := range tests {
+		got, err := parseConfFile(strings.NewReader(test.input))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestConfFileValues(t *testing.T) {
+	conf, err := parseConfFile(strings.NewReader("request_rules\n\tredirect\n\tauth\nport x\nenabled Yes\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := conf.list("request_rules"); !reflect.DeepEqual(got, []string{"redirect", "auth"}) {
+		t.Errorf("list: got %q", got)
+	}
+	if got := conf.int("port", 80); got != 80 {
+		t.Errorf("int: got %d, want default 80", got)
+	}
+	if !conf.bool("enabled", false) {
+		t.Error("bool: enabled is not true")
+	}
+}
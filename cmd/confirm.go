@@ -0,0 +1,65 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// assumeYes is set with --yes to skip confirmation prompts.
+var assumeYes bool
+
+var stdin = bufio.NewReader(os.Stdin)
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// prompt prints question and returns the answer typed on stdin.
+func prompt(question string) string {
+	fmt.Print(question)
+	line, _ := stdin.ReadString('\n')
+
+	return strings.TrimSpace(line)
+}
+
+// confirmChanges asks whether the listed changes should be applied. Only
+// interactive sessions are asked, scripts are expected to use --dry-run to
+// check what they do.
+func confirmChanges(changes []change) bool {
+	if assumeYes || !isTerminal(os.Stdin) {
+		return true
+	}
+
+	objects := make(map[string]bool)
+	for _, c := range changes {
+		objects[c.Kind+" "+c.Name] = true
+	}
+
+	answer := prompt(fmt.Sprintf("Apply %d change(s) to %d object(s) on %s? [y/N] ", len(changes), len(objects), currentConnection().URL))
+	answer = strings.ToLower(answer)
+
+	return answer == "y" || answer == "yes"
+}
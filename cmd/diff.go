@@ -0,0 +1,284 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/gobwas/glob"
+	"github.com/mgutz/ansi"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffVserverGlob string
+	diffPoolGlob    string
+	diffRuleGlob    string
+)
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff [source] [source]",
+	Short: "Compare vserver, pool and rule configuration of two sources",
+	Long: `Compare vserver, pool and rule configuration of two sources object by object.
+
+A source is a profile from the config file, an http(s) URL that is accessed
+with the current credentials, or a directory written by the snapshot command.
+
+Objects that only exist in the second source are reported as added, objects
+that only exist in the first one as removed. The command exits with status 1
+if any difference was found.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 2 {
+			return errors.New("Missing argument(s)")
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		diff(args[0], args[1])
+	},
+}
+
+type fieldDiff struct {
+	Field string  `json:"field"`
+	From  *string `json:"from"`
+	To    *string `json:"to"`
+}
+
+type objectDiff struct {
+	Kind   string      `json:"kind"`
+	Name   string      `json:"name"`
+	Status string      `json:"status"`
+	Fields []fieldDiff `json:"fields,omitempty"`
+}
+
+func diff(from, to string) {
+	a, err := openSource(from)
+	if err != nil {
+		log.Fatal(err)
+	}
+	b, err := openSource(to)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	globs := map[string]glob.Glob{
+		"vserver": glob.MustCompile(diffVserverGlob),
+		"pool":    glob.MustCompile(diffPoolGlob),
+		"rule":    glob.MustCompile(diffRuleGlob),
+	}
+
+	diffs := []objectDiff{}
+
+	for _, kind := range configKinds {
+		progress("Getting", kind.Name, "list from", a, "and", b)
+		namesA, err := listMatching(a, kind, globs[kind.Name])
+		if err != nil {
+			log.Fatal(err)
+		}
+		namesB, err := listMatching(b, kind, globs[kind.Name])
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		for _, name := range mergeNames(namesA, namesB) {
+			switch {
+			case !namesB[name]:
+				diffs = append(diffs, objectDiff{Kind: kind.Name, Name: name, Status: "removed"})
+			case !namesA[name]:
+				diffs = append(diffs, objectDiff{Kind: kind.Name, Name: name, Status: "added"})
+			default:
+				fieldsA, err := readFields(a, kind, name)
+				if err != nil {
+					log.Fatal(err)
+				}
+				fieldsB, err := readFields(b, kind, name)
+				if err != nil {
+					log.Fatal(err)
+				}
+
+				if fields := diffFields(fieldsA, fieldsB); len(fields) > 0 {
+					diffs = append(diffs, objectDiff{Kind: kind.Name, Name: name, Status: "changed", Fields: fields})
+				}
+			}
+		}
+	}
+
+	if jsonOutput() {
+		printJSON(diffs)
+	} else {
+		printDiffs(diffs)
+	}
+
+	if len(diffs) > 0 {
+		os.Exit(1)
+	}
+}
+
+func listMatching(source configSource, kind configKind, g glob.Glob) (map[string]bool, error) {
+	names, err := source.listObjects(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	matching := make(map[string]bool)
+	for _, name := range names {
+		if g.Match(name) {
+			matching[name] = true
+		}
+	}
+
+	return matching, nil
+}
+
+func mergeNames(sets ...map[string]bool) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, set := range sets {
+		for name := range set {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// readFields flattens a configuration document into "section.field" keys with
+// compact JSON values. Rules are TrafficScript source and compared as a whole.
+func readFields(source configSource, kind configKind, name string) (map[string]string, error) {
+	data, err := source.readObject(kind, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if kind.Name == "rule" {
+		return map[string]string{"content": string(data)}, nil
+	}
+
+	var doc struct {
+		Properties map[string]map[string]json.RawMessage `json:"properties"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s %s: %v", kind.Name, name, err)
+	}
+
+	fields := make(map[string]string)
+	for section, values := range doc.Properties {
+		for field, value := range values {
+			var buf bytes.Buffer
+			if err := json.Compact(&buf, value); err != nil {
+				return nil, err
+			}
+			fields[section+"."+field] = buf.String()
+		}
+	}
+
+	return fields, nil
+}
+
+func diffFields(a, b map[string]string) []fieldDiff {
+	var diffs []fieldDiff
+	for _, field := range mergeNames(keySet(a), keySet(b)) {
+		valueA, inA := a[field]
+		valueB, inB := b[field]
+		if inA && inB && valueA == valueB {
+			continue
+		}
+
+		d := fieldDiff{Field: field}
+		if inA {
+			d.From = &valueA
+		}
+		if inB {
+			d.To = &valueB
+		}
+		diffs = append(diffs, d)
+	}
+
+	return diffs
+}
+
+func keySet(m map[string]string) map[string]bool {
+	set := make(map[string]bool, len(m))
+	for k := range m {
+		set[k] = true
+	}
+
+	return set
+}
+
+func printDiffs(diffs []objectDiff) {
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 5, 2, ' ', 0)
+
+	for _, d := range diffs {
+		switch d.Status {
+		case "added":
+			fmt.Fprint(w, ansi.Color("+", "green"), " ", d.Kind, " ", d.Name, "\n")
+		case "removed":
+			fmt.Fprint(w, ansi.Color("-", "red"), " ", d.Kind, " ", d.Name, "\n")
+		default:
+			fmt.Fprint(w, "~ ", d.Kind, " ", d.Name, "\n")
+			for _, f := range d.Fields {
+				if d.Kind == "rule" {
+					fmt.Fprint(w, "\t", f.Field, ":\t(changed)\n")
+					continue
+				}
+				fmt.Fprint(w, "\t", f.Field, ":\t", diffValue(f.From), " -> ", diffValue(f.To), "\n")
+			}
+		}
+	}
+
+	if len(diffs) == 0 {
+		fmt.Fprint(w, "(no differences)\n")
+	}
+
+	w.Flush()
+}
+
+func diffValue(v *string) string {
+	if v == nil {
+		return "(unset)"
+	}
+
+	return *v
+}
+
+func init() {
+	RootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().StringVar(&diffVserverGlob, "vserver", "*", "Only compare vservers matching this glob.")
+	diffCmd.Flags().StringVar(&diffPoolGlob, "pool", "*", "Only compare pools matching this glob.")
+	diffCmd.Flags().StringVar(&diffRuleGlob, "rule", "*", "Only compare rules matching this glob.")
+	diffCmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table or json).")
+}
@@ -28,7 +28,6 @@ import (
 
 	"github.com/gobwas/glob"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 var disableRuleCmd = &cobra.Command{
@@ -53,13 +52,15 @@ func disableRule(targetVserver string, targetRule string) {
 	vserverGlob := glob.MustCompile(targetVserver)
 	client := initClient()
 
-	fmt.Println("Getting vserver list from", viper.Get("vtmAPIUrl"))
+	fmt.Println("Getting vserver list from", currentConnection().URL)
 	serverlist, resp, err := client.ListVirtualServers()
 	if err != nil {
 		log.Fatal(err)
 	}
 	fmt.Println("Response:", resp.Status)
 
+	var changes []change
+
 	for _, vserver := range serverlist {
 		if !vserverGlob.Match(vserver) {
 			continue
@@ -70,7 +71,8 @@ func disableRule(targetVserver string, targetRule string) {
 			log.Fatal(err)
 		}
 
-		rules := *r.Basic.RequestRules
+		// Copy the rules, r still has to hold the current state.
+		rules := append([]string(nil), *r.Basic.RequestRules...)
 		hasUpdates := false
 		hasRule := false
 
@@ -91,20 +93,17 @@ func disableRule(targetVserver string, targetRule string) {
 
 		if hasUpdates {
 			fmt.Print(vserver, ":\t", targetRule, " [enabled] -> [", disabledC, "]\n")
-			if !dryRun {
-				r.Basic.RequestRules = &rules
-
-				_, err = client.Set(r)
-				if err != nil {
-					log.Fatal(err)
-				}
-			}
+			changes = append(changes, newChange("vserver", vserver, "basic.request_rules", r, rules))
 		} else {
 			fmt.Print(vserver, ":\t", targetRule, " [", disabledC, "] (no change)\n")
 		}
 	}
+
+	applyChanges(&client, changes)
 }
 
 func init() {
 	vserverCmd.AddCommand(disableRuleCmd)
+
+	addChangeFlags(disableRuleCmd)
 }
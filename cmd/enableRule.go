@@ -28,7 +28,6 @@ import (
 
 	"github.com/gobwas/glob"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 var enableRuleCmd = &cobra.Command{
@@ -53,13 +52,15 @@ func enableRule(targetVserver, targetRule string) {
 	vserverGlob := glob.MustCompile(targetVserver)
 	client := initClient()
 
-	fmt.Println("Getting vserver list from", viper.Get("vtmAPIUrl"))
+	fmt.Println("Getting vserver list from", currentConnection().URL)
 	serverlist, resp, err := client.ListVirtualServers()
 	if err != nil {
 		log.Fatal(err)
 	}
 	fmt.Println("Response:", resp.Status)
 
+	var changes []change
+
 	for _, vserver := range serverlist {
 		if !vserverGlob.Match(vserver) {
 			continue
@@ -70,7 +71,8 @@ func enableRule(targetVserver, targetRule string) {
 			log.Fatal(err)
 		}
 
-		rules := *r.Basic.RequestRules
+		// Copy the rules, r still has to hold the current state.
+		rules := append([]string(nil), *r.Basic.RequestRules...)
 		hasUpdates := false
 		hasRule := false
 
@@ -91,20 +93,17 @@ func enableRule(targetVserver, targetRule string) {
 
 		if hasUpdates {
 			fmt.Print(vserver, ":\t", targetRule, " [disabled] -> [", enabledC, "]\n")
-			if !dryRun {
-				r.Basic.RequestRules = &rules
-
-				_, err = client.Set(r)
-				if err != nil {
-					log.Fatal(err)
-				}
-			}
+			changes = append(changes, newChange("vserver", vserver, "basic.request_rules", r, rules))
 		} else {
 			fmt.Print(vserver, ":\t", targetRule, " [", enabledC, "] (no change)\n")
 		}
 	}
+
+	applyChanges(&client, changes)
 }
 
 func init() {
 	vserverCmd.AddCommand(enableRuleCmd)
+
+	addChangeFlags(enableRuleCmd)
 }
@@ -0,0 +1,260 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// overrideFreeze is the reason given with --override-freeze.
+var overrideFreeze string
+
+// frozenBy describes the freeze window that was overridden, for the audit log.
+var frozenBy string
+
+// freezeWindow is an entry of the freezes list in the config file. A window
+// is either a cron expression, which freezes every minute it matches, or a
+// date range [from, to). Windows without profiles apply to all clusters.
+//
+//	freezes:
+//	  - name: month-end billing
+//	    cron: "* * 28-31 * *"
+//	  - name: new year
+//	    from: 2017-12-22
+//	    to: 2018-01-04
+//	    profiles: [production]
+type freezeWindow struct {
+	Name     string   `mapstructure:"name"`
+	Cron     string   `mapstructure:"cron"`
+	From     string   `mapstructure:"from"`
+	To       string   `mapstructure:"to"`
+	Profiles []string `mapstructure:"profiles"`
+}
+
+func (w freezeWindow) String() string {
+	if w.Cron != "" {
+		return "cron " + w.Cron
+	}
+
+	return w.From + " - " + w.To
+}
+
+// active reports whether the window covers t.
+func (w freezeWindow) active(t time.Time) (bool, error) {
+	if w.Cron != "" {
+		expr, err := parseCron(w.Cron)
+		if err != nil {
+			return false, fmt.Errorf("freeze %q: %v", w.Name, err)
+		}
+
+		return expr.match(t), nil
+	}
+
+	from, err := parseLocalTime(w.From)
+	if err != nil {
+		return false, fmt.Errorf("freeze %q: %v", w.Name, err)
+	}
+	to, err := parseLocalTime(w.To)
+	if err != nil {
+		return false, fmt.Errorf("freeze %q: %v", w.Name, err)
+	}
+
+	return !t.Before(from) && t.Before(to), nil
+}
+
+func (w freezeWindow) appliesTo(profile string) bool {
+	if len(w.Profiles) == 0 {
+		return true
+	}
+
+	for _, p := range w.Profiles {
+		if p == profile {
+			return true
+		}
+	}
+
+	return false
+}
+
+// activeFreeze returns the freeze window covering t for the current profile.
+func activeFreeze(t time.Time) (*freezeWindow, error) {
+	var windows []freezeWindow
+	if err := viper.UnmarshalKey("freezes", &windows); err != nil {
+		return nil, fmt.Errorf("freezes: %v", err)
+	}
+
+	profile := viper.GetString("profile")
+	for i, w := range windows {
+		if !w.appliesTo(profile) {
+			continue
+		}
+
+		active, err := w.active(t)
+		if err != nil {
+			return nil, err
+		}
+		if active {
+			return &windows[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// enforceFreeze exits if a change freeze is active at t and not overridden.
+// A dry run only warns, it changes nothing.
+func enforceFreeze(t time.Time) {
+	w, err := activeFreeze(t)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if w == nil {
+		return
+	}
+
+	if overrideFreeze == "" && dryRun {
+		fmt.Printf("Warning: change freeze %q (%s) is active, the changes would need --override-freeze\n", w.Name, w)
+		return
+	}
+	if overrideFreeze == "" {
+		log.Fatalf("Blocked by change freeze %q (%s), use --override-freeze \"reason\" to proceed anyway", w.Name, w)
+	}
+
+	frozenBy = fmt.Sprintf("%s: %s", w.Name, overrideFreeze)
+	fmt.Printf("Overriding change freeze %q (%s): %s\n", w.Name, w, overrideFreeze)
+}
+
+var timeLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// parseLocalTime parses a date or date and time in local time, or an RFC 3339
+// timestamp.
+func parseLocalTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("cannot parse time %q, use e.g. 2017-11-01T02:00", s)
+}
+
+// cronExpr is a standard five field cron expression
+// (minute hour day-of-month month day-of-week).
+type cronExpr struct {
+	fields         [5]map[int]bool
+	anyDom, anyDow bool
+}
+
+var cronBounds = [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+
+func parseCron(expr string) (*cronExpr, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("cron expression %q needs 5 fields", expr)
+	}
+
+	c := &cronExpr{
+		anyDom: strings.HasPrefix(parts[2], "*"),
+		anyDow: strings.HasPrefix(parts[4], "*"),
+	}
+
+	for i, part := range parts {
+		min, max := cronBounds[i][0], cronBounds[i][1]
+		values := make(map[int]bool)
+
+		for _, item := range strings.Split(part, ",") {
+			lo, hi, step := min, max, 1
+
+			if j := strings.Index(item, "/"); j >= 0 {
+				n, err := strconv.Atoi(item[j+1:])
+				if err != nil || n < 1 {
+					return nil, fmt.Errorf("invalid step in cron field %q", part)
+				}
+				item, step = item[:j], n
+			}
+
+			if item != "*" {
+				bounds := strings.SplitN(item, "-", 2)
+				var err error
+				if lo, err = strconv.Atoi(bounds[0]); err != nil {
+					return nil, fmt.Errorf("invalid cron field %q", part)
+				}
+				hi = lo
+				if len(bounds) == 2 {
+					if hi, err = strconv.Atoi(bounds[1]); err != nil {
+						return nil, fmt.Errorf("invalid cron field %q", part)
+					}
+				} else if step > 1 {
+					hi = max
+				}
+			}
+
+			if lo < min || hi > max || lo > hi {
+				return nil, fmt.Errorf("cron field %q out of range %d-%d", part, min, max)
+			}
+
+			for v := lo; v <= hi; v += step {
+				values[v] = true
+			}
+		}
+
+		c.fields[i] = values
+	}
+
+	// Both 0 and 7 mean Sunday.
+	if c.fields[4][7] {
+		c.fields[4][0] = true
+	}
+
+	return c, nil
+}
+
+func (c *cronExpr) match(t time.Time) bool {
+	if !c.fields[0][t.Minute()] || !c.fields[1][t.Hour()] || !c.fields[3][int(t.Month())] {
+		return false
+	}
+
+	dom := c.fields[2][t.Day()]
+	dow := c.fields[4][int(t.Weekday())]
+
+	// Like cron, match either day field if both are restricted.
+	if c.anyDom || c.anyDow {
+		return dom && dow
+	}
+
+	return dom || dow
+}
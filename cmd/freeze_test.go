@@ -0,0 +1,127 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronMatch(t *testing.T) {
+	// 2017-11-01 is a Wednesday.
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2017, 11, day, hour, min, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		expr string
+		t    time.Time
+		want bool
+	}{
+		{"* * * * 1-5", at(6, 10, 0), true},  // Monday
+		{"* * * * 1-5", at(3, 10, 0), true},  // Friday
+		{"* * * * 1-5", at(4, 10, 0), false}, // Saturday
+		{"* * * * 1-5", at(5, 10, 0), false}, // Sunday
+		{"* * * * 5-7", at(5, 10, 0), true},  // Sunday as 7
+		{"* * * * 5-7", at(4, 10, 0), true},  // Saturday
+		{"* * * * 5-7", at(6, 10, 0), false}, // Monday
+		{"* * * * 0", at(5, 10, 0), true},
+		{"* * * * 7", at(5, 10, 0), true},
+		{"* * * * 1,3,5", at(1, 10, 0), true},
+		{"* * * * 1,3,5", at(7, 10, 0), false},
+		{"* * * * 1-5/2", at(3, 10, 0), true},  // Friday
+		{"* * * * 1-5/2", at(2, 10, 0), false}, // Thursday
+		{"0-30 22-23 * * 1-5", at(1, 22, 15), true},
+		{"0-30 22-23 * * 1-5", at(1, 22, 45), false},
+		{"0-30 22-23 * * 1-5", at(1, 21, 15), false},
+		{"*/15 * * * *", at(1, 10, 30), true},
+		{"*/15 * * * *", at(1, 10, 31), false},
+		{"* * 28-31 * *", at(28, 0, 0), true},
+		{"* * 28-31 * *", at(27, 23, 59), false},
+		{"* * * 12 *", at(1, 0, 0), false},
+		// Either day field matches if both are restricted.
+		{"* * 1 * 1", at(1, 10, 0), true},
+		{"* * 1 * 1", at(6, 10, 0), true},
+		{"* * 1 * 1", at(7, 10, 0), false},
+		// Both have to match if one starts with *.
+		{"* * */2 * 1", at(6, 10, 0), false},
+		{"* * */2 * 1", at(13, 10, 0), true},
+	}
+
+	for _, test := range tests {
+		c, err := parseCron(test.expr)
+		if err != nil {
+			t.Errorf("%s: %v", test.expr, err)
+			continue
+		}
+		if got := c.match(test.t); got != test.want {
+			t.Errorf("%s at %s: got %v, want %v", test.expr, test.t.Format("Mon 2006-01-02 15:04"), got, test.want)
+		}
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{
+		"* * * *",
+		"* * * * * *",
+		"* * * * 8",
+		"* * * * 5-1",
+		"60 * * * *",
+		"* * 0 * *",
+		"*/0 * * * *",
+		"x * * * *",
+		"* * * * mon",
+	} {
+		if _, err := parseCron(expr); err == nil {
+			t.Errorf("%s: no error", expr)
+		}
+	}
+}
+
+func TestFreezeWindowActive(t *testing.T) {
+	tests := []struct {
+		window freezeWindow
+		t      string
+		want   bool
+	}{
+		{freezeWindow{From: "2017-12-22", To: "2018-01-04"}, "2017-12-22T00:00", true},
+		{freezeWindow{From: "2017-12-22", To: "2018-01-04"}, "2018-01-03T23:59", true},
+		{freezeWindow{From: "2017-12-22", To: "2018-01-04"}, "2018-01-04T00:00", false},
+		{freezeWindow{From: "2017-12-22", To: "2018-01-04"}, "2017-12-21T23:59", false},
+		{freezeWindow{Cron: "* 9-17 * * 6-7"}, "2017-11-05T12:00", true},
+		{freezeWindow{Cron: "* 9-17 * * 6-7"}, "2017-11-06T12:00", false},
+	}
+
+	for _, test := range tests {
+		at, err := parseLocalTime(test.t)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := test.window.active(at)
+		if err != nil {
+			t.Errorf("%s: %v", test.window, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s at %s: got %v, want %v", test.window, test.t, got, test.want)
+		}
+	}
+}
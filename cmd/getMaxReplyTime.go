@@ -23,13 +23,11 @@ package cmd
 import (
 	"errors"
 	"fmt"
-	"log"
-	"os"
+	"io"
 	"text/tabwriter"
 
 	"github.com/gobwas/glob"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 // getMaxReplyTimeCmd represents the getMaxReplyTime command
@@ -44,23 +42,27 @@ var getMaxReplyTimeCmd = &cobra.Command{
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		getMaxReplyTime(args[0])
+		watch(func(out io.Writer) error {
+			return getMaxReplyTime(out, args[0])
+		})
 	},
 }
 
-func getMaxReplyTime(targetPool string) {
+func getMaxReplyTime(out io.Writer, targetPool string) error {
 	poolGlob := glob.MustCompile(targetPool)
-	client := initClient()
+	client := initReader()
 
-	fmt.Println("Getting pool list from", viper.Get("vtmAPIUrl"))
+	progress("Getting pool list from", readerName())
 	poollist, resp, err := client.ListPools()
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+	if resp != nil {
+		progress("Response:", resp.Status)
 	}
-	fmt.Println("Response:", resp.Status)
 
 	w := new(tabwriter.Writer)
-	w.Init(os.Stdout, 0, 5, 2, ' ', 0)
+	w.Init(out, 0, 5, 2, ' ', 0)
 
 	for _, pool := range poollist {
 		if !poolGlob.Match(pool) {
@@ -69,15 +71,17 @@ func getMaxReplyTime(targetPool string) {
 
 		r, _, err := client.GetPool(pool)
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
 
 		fmt.Fprint(w, pool, ":\t", *r.Connection.MaxReplyTime, "s\n")
 	}
 
-	w.Flush()
+	return w.Flush()
 }
 
 func init() {
 	poolCmd.AddCommand(getMaxReplyTimeCmd)
+
+	getMaxReplyTimeCmd.Flags().DurationVar(&watchInterval, "watch", 0, "Re-run at this interval, highlighting changed lines.")
 }
@@ -23,14 +23,12 @@ package cmd
 import (
 	"errors"
 	"fmt"
-	"log"
-	"os"
+	"io"
 	"strings"
 	"text/tabwriter"
 
 	"github.com/gobwas/glob"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 // getRuleStateCmd represents the getRuleState command
@@ -44,24 +42,28 @@ var getRuleStateCmd = &cobra.Command{
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		getRuleState(args[0], args[1])
+		watch(func(out io.Writer) error {
+			return getRuleState(out, args[0], args[1])
+		})
 	},
 }
 
-func getRuleState(targetVserver, targetRule string) {
+func getRuleState(out io.Writer, targetVserver, targetRule string) error {
 	vserverGlob := glob.MustCompile(targetVserver)
 	ruleGlob := glob.MustCompile(targetRule)
-	client := initClient()
+	client := initReader()
 
-	fmt.Println("Getting vserver list from", viper.Get("vtmAPIUrl"))
+	progress("Getting vserver list from", readerName())
 	serverlist, resp, err := client.ListVirtualServers()
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+	if resp != nil {
+		progress("Response:", resp.Status)
 	}
-	fmt.Println("Response:", resp.Status)
 
 	w := new(tabwriter.Writer)
-	w.Init(os.Stdout, 0, 5, 2, ' ', 0)
+	w.Init(out, 0, 5, 2, ' ', 0)
 
 	for _, vserver := range serverlist {
 		if !vserverGlob.Match(vserver) {
@@ -72,7 +74,7 @@ func getRuleState(targetVserver, targetRule string) {
 
 		r, _, err := client.GetVirtualServer(vserver)
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
 
 		rules := *r.Basic.RequestRules
@@ -99,9 +101,11 @@ func getRuleState(targetVserver, targetRule string) {
 		}
 	}
 
-	w.Flush()
+	return w.Flush()
 }
 
 func init() {
 	vserverCmd.AddCommand(getRuleStateCmd)
+
+	getRuleStateCmd.Flags().DurationVar(&watchInterval, "watch", 0, "Re-run at this interval, highlighting changed lines.")
 }
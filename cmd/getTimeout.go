@@ -23,14 +23,11 @@ package cmd
 import (
 	"errors"
 	"fmt"
-	"log"
-
-	"os"
+	"io"
 	"text/tabwriter"
 
 	"github.com/gobwas/glob"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 // getTimeoutCmd represents the getTimeout command
@@ -45,23 +42,27 @@ var getTimeoutCmd = &cobra.Command{
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		getTimeout(args[0])
+		watch(func(out io.Writer) error {
+			return getTimeout(out, args[0])
+		})
 	},
 }
 
-func getTimeout(targetVserver string) {
+func getTimeout(out io.Writer, targetVserver string) error {
 	vserverGlob := glob.MustCompile(targetVserver)
-	client := initClient()
+	client := initReader()
 
-	fmt.Println("Getting vserver list from", viper.Get("vtmAPIUrl"))
+	progress("Getting vserver list from", readerName())
 	serverlist, resp, err := client.ListVirtualServers()
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+	if resp != nil {
+		progress("Response:", resp.Status)
 	}
-	fmt.Println("Response:", resp.Status)
 
 	w := new(tabwriter.Writer)
-	w.Init(os.Stdout, 0, 5, 2, ' ', 0)
+	w.Init(out, 0, 5, 2, ' ', 0)
 
 	for _, vserver := range serverlist {
 		if !vserverGlob.Match(vserver) {
@@ -70,15 +71,17 @@ func getTimeout(targetVserver string) {
 
 		r, _, err := client.GetVirtualServer(vserver)
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
 
 		fmt.Fprint(w, vserver, ":\t", *r.Connection.Timeout, "s\n")
 	}
 
-	w.Flush()
+	return w.Flush()
 }
 
 func init() {
 	vserverCmd.AddCommand(getTimeoutCmd)
+
+	getTimeoutCmd.Flags().DurationVar(&watchInterval, "watch", 0, "Re-run at this interval, highlighting changed lines.")
 }
@@ -0,0 +1,69 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List operations recorded in the change journal",
+	Run: func(cmd *cobra.Command, args []string) {
+		history()
+	},
+}
+
+func history() {
+	entries, err := loadJournal()
+	if err != nil {
+		log.Fatal(err)
+	}
+	reverted := undone(entries)
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 5, 2, ' ', 0)
+
+	for _, e := range entries {
+		state := ""
+		if reverted[e.ID] {
+			state = " (undone)"
+		}
+
+		fmt.Fprint(w, e.ID, "\t", e.URL, "\t", len(e.Changes), " changes", state, "\t", e.Command, "\n")
+	}
+
+	if len(entries) == 0 {
+		fmt.Fprint(w, "(no recorded operations)\n")
+	}
+
+	w.Flush()
+}
+
+func init() {
+	RootCmd.AddCommand(historyCmd)
+}
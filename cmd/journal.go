@@ -0,0 +1,154 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/spf13/viper"
+)
+
+// journalEntry records the changes made by one invocation of a write command,
+// so they can be listed with history and reverted with undo.
+type journalEntry struct {
+	ID      string    `json:"id"`
+	Time    time.Time `json:"time"`
+	URL     string    `json:"url"`
+	Command string    `json:"command"`
+	UndoOf  string    `json:"undo_of,omitempty"`
+	Changes []change  `json:"changes"`
+}
+
+// stateDir returns the directory ~/.go-vtm-cli/<name> used for local state.
+func stateDir(name string) (string, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".go-vtm-cli", name), nil
+}
+
+// journalDir returns the journal directory, ~/.go-vtm-cli/journal unless set
+// with journalDir in the config file.
+func journalDir() (string, error) {
+	if dir := viper.GetString("journalDir"); dir != "" {
+		return homedir.Expand(dir)
+	}
+
+	return stateDir("journal")
+}
+
+func newJournalEntry() *journalEntry {
+	return newJournalEntryFor(currentConnection().URL)
+}
+
+// newJournalEntryFor returns an entry for changes made to the vTM at url.
+func newJournalEntryFor(url string) *journalEntry {
+	now := time.Now()
+
+	return &journalEntry{
+		ID:      now.Format("20060102-150405.000"),
+		Time:    now,
+		URL:     url,
+		Command: strings.Join(os.Args, " "),
+	}
+}
+
+// save writes the entry to the journal directory unless it is empty. The
+// changes have already been applied at this point, so failing to write the
+// journal only results in a warning.
+func (e *journalEntry) save() {
+	if len(e.Changes) == 0 {
+		return
+	}
+
+	err := func() error {
+		dir, err := journalDir()
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return err
+		}
+
+		data, err := json.MarshalIndent(e, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		return ioutil.WriteFile(filepath.Join(dir, e.ID+".json"), data, 0600)
+	}()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Warning: could not write journal:", err)
+	}
+}
+
+// loadJournal returns all journal entries, oldest first.
+func loadJournal() ([]*journalEntry, error) {
+	dir, err := journalDir()
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*journalEntry
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		entry := new(journalEntry)
+		if err := json.Unmarshal(data, entry); err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+
+	return entries, nil
+}
+
+// undone returns the IDs of entries that have been reverted by undo.
+func undone(entries []*journalEntry) map[string]bool {
+	ids := make(map[string]bool)
+	for _, e := range entries {
+		if e.UndoOf != "" {
+			ids[e.UndoOf] = true
+		}
+	}
+
+	return ids
+}
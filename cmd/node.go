@@ -0,0 +1,116 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"net"
+
+	"github.com/martinlindner/go-vtm"
+	"github.com/spf13/cobra"
+)
+
+// nodeCmd represents the node command
+var nodeCmd = &cobra.Command{
+	Use:   "node",
+	Short: "node subcommands, acting on a backend host across all pools",
+}
+
+// hostMatch reports whether the pool node host:port is on host. host may also
+// be given as host:port to select a single node.
+func hostMatch(node, host string) bool {
+	if node == host {
+		return true
+	}
+
+	h, _, err := net.SplitHostPort(node)
+
+	return err == nil && h == host
+}
+
+// setNodeState sets the state of every node on host in every pool to state.
+func setNodeState(host, state string) {
+	if dryRun {
+		fmt.Println(dryRunC)
+	}
+
+	client := initClient()
+
+	fmt.Println("Getting pool list from", currentConnection().URL)
+	poollist, resp, err := client.ListPools()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Response:", resp.Status)
+
+	var changes []change
+	found := 0
+
+	for _, pool := range poollist {
+		r, _, err := client.GetPool(pool)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if r.Basic.NodesTable == nil {
+			continue
+		}
+
+		// Copy the nodes, r still has to hold the current state.
+		table := append(stingray.NodesTable(nil), *r.Basic.NodesTable...)
+		hasUpdates := false
+
+		for index, n := range table {
+			if n.Node == nil || !hostMatch(*n.Node, host) {
+				continue
+			}
+			found++
+
+			current := nodeState(n)
+			if current == state {
+				fmt.Print(pool, ":\t", *n.Node, " [", state, "] (no change)\n")
+				continue
+			}
+
+			newState := state
+			n.State = &newState
+			table[index] = n
+			hasUpdates = true
+			fmt.Print(pool, ":\t", *n.Node, " [", current, "] -> [", state, "]\n")
+		}
+
+		if hasUpdates {
+			changes = append(changes, newChange("pool", pool, "basic.nodes_table", r, table))
+		}
+	}
+
+	if found == 0 {
+		log.Fatal("Host ", host, " is not a node of any pool")
+	}
+	fmt.Print(host, ":\t", found, " node(s), ", len(changes), " pool(s) to change\n")
+
+	applyChanges(&client, changes)
+}
+
+func init() {
+	RootCmd.AddCommand(nodeCmd)
+}
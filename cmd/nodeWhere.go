@@ -0,0 +1,114 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// nodeWhereCmd represents the node where command
+var nodeWhereCmd = &cobra.Command{
+	Use:   "where [host]",
+	Short: "List every pool containing [host]",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("Missing argument(s)")
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		found := true
+		watch(func(out io.Writer) (err error) {
+			found, err = nodeWhere(out, args[0])
+			return err
+		})
+
+		if !found {
+			os.Exit(1)
+		}
+	},
+}
+
+type nodeLocation struct {
+	Pool   string `json:"pool"`
+	Node   string `json:"node"`
+	State  string `json:"state"`
+	Weight int    `json:"weight"`
+}
+
+func nodeWhere(out io.Writer, host string) (bool, error) {
+	reader := initReader()
+
+	progress("Getting pool list from", readerName())
+	poollist, resp, err := reader.ListPools()
+	if err != nil {
+		return false, err
+	}
+	if resp != nil {
+		progress("Response:", resp.Status)
+	}
+
+	var locations []nodeLocation
+
+	for _, pool := range poollist {
+		r, _, err := reader.GetPool(pool)
+		if err != nil {
+			return false, err
+		}
+
+		if r.Basic.NodesTable == nil {
+			continue
+		}
+
+		for _, n := range *r.Basic.NodesTable {
+			if n.Node != nil && hostMatch(*n.Node, host) {
+				locations = append(locations, nodeLocation{pool, *n.Node, nodeState(n), nodeWeight(n)})
+			}
+		}
+	}
+
+	if jsonOutput() {
+		fprintJSON(out, locations)
+	} else {
+		w := new(tabwriter.Writer)
+		w.Init(out, 0, 5, 2, ' ', 0)
+		for _, l := range locations {
+			fmt.Fprint(w, l.Pool, ":\t", l.Node, "\t", l.State, "\tweight ", l.Weight, "\n")
+		}
+		w.Flush()
+	}
+
+	return len(locations) > 0, nil
+}
+
+func init() {
+	nodeCmd.AddCommand(nodeWhereCmd)
+
+	nodeWhereCmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table or json).")
+	nodeWhereCmd.Flags().DurationVar(&watchInterval, "watch", 0, "Re-run at this interval, highlighting changed lines.")
+}
@@ -0,0 +1,289 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/martinlindner/go-vtm"
+)
+
+// offlinePath is the backup archive or configuration directory given with
+// --offline.
+var offlinePath string
+
+// configReader is the read-only part of the go-vtm client used by the get
+// commands. Besides the live client it is implemented by backupConfig.
+type configReader interface {
+	ListVirtualServers() ([]string, *http.Response, error)
+	GetVirtualServer(name string) (*stingray.VirtualServer, *http.Response, error)
+	ListPools() ([]string, *http.Response, error)
+	GetPool(name string) (*stingray.Pool, *http.Response, error)
+}
+
+// initReader returns the backup given with --offline, or a client for the
+// current cluster.
+func initReader() configReader {
+	if offlinePath == "" {
+		client := initClient()
+		return &client
+	}
+
+	backup, err := loadBackup(offlinePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return backup
+}
+
+// readerName describes where initReader reads from.
+func readerName() string {
+	if offlinePath != "" {
+		return offlinePath
+	}
+
+	return currentConnection().URL
+}
+
+// backupConfig is the vserver and pool configuration found in a traffic
+// manager backup archive (optionally gzipped tar) or in an extracted
+// configuration directory.
+type backupConfig struct {
+	vservers map[string]confFile
+	pools    map[string]confFile
+}
+
+func loadBackup(path string) (*backupConfig, error) {
+	b := &backupConfig{
+		vservers: make(map[string]confFile),
+		pools:    make(map[string]confFile),
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if fi.IsDir() {
+		err = b.readDir(path)
+	} else {
+		err = b.readArchive(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	if len(b.vservers) == 0 && len(b.pools) == 0 {
+		return nil, fmt.Errorf("%s: no vserver or pool configuration found", path)
+	}
+
+	return b, nil
+}
+
+func (b *backupConfig) readDir(dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		return b.add(filepath.ToSlash(path), f)
+	})
+}
+
+func (b *backupConfig) readArchive(path string) error {
+	return walkArchive(path, b.add)
+}
+
+// isGzip reports whether r starts with the gzip magic number.
+func isGzip(r *bufio.Reader) bool {
+	magic, _ := r.Peek(2)
+
+	return len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b
+}
+
+// walkArchive calls fn for every regular file in a tar archive, which may be
+// gzip compressed.
+func walkArchive(path string, fn func(name string, r io.Reader) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = bufio.NewReader(f)
+	if isGzip(r.(*bufio.Reader)) {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if !hdr.FileInfo().Mode().IsRegular() {
+			continue
+		}
+
+		if err := fn(hdr.Name, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// add parses the file at path if it is a vserver or pool configuration file,
+// i.e. its path ends in conf/vservers/<name> or conf/pools/<name>.
+func (b *backupConfig) add(path string, r io.Reader) error {
+	parts := strings.Split(path, "/")
+	if len(parts) < 3 || parts[len(parts)-3] != "conf" {
+		return nil
+	}
+
+	name := parts[len(parts)-1]
+	if strings.HasPrefix(name, ".") {
+		return nil
+	}
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		name = unescaped
+	}
+
+	var objects map[string]confFile
+	switch parts[len(parts)-2] {
+	case "vservers":
+		objects = b.vservers
+	case "pools":
+		objects = b.pools
+	default:
+		return nil
+	}
+
+	conf, err := parseConfFile(r)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	objects[name] = conf
+
+	return nil
+}
+
+func (b *backupConfig) ListVirtualServers() ([]string, *http.Response, error) {
+	return confNames(b.vservers), nil, nil
+}
+
+func (b *backupConfig) GetVirtualServer(name string) (*stingray.VirtualServer, *http.Response, error) {
+	conf, ok := b.vservers[name]
+	if !ok {
+		return nil, nil, fmt.Errorf("vserver %s not found in backup", name)
+	}
+
+	r := stingray.NewVirtualServer(name)
+
+	enabled := conf.bool("enabled", false)
+	r.Basic.Enabled = &enabled
+	if pool, ok := conf["pool"]; ok {
+		r.Basic.Pool = &pool
+	}
+	port := conf.int("port", 0)
+	r.Basic.Port = &port
+	rules := conf.list("request_rules")
+	r.Basic.RequestRules = &rules
+	timeout := conf.int("timeout", 300)
+	r.Connection.Timeout = &timeout
+
+	return r, nil, nil
+}
+
+func (b *backupConfig) ListPools() ([]string, *http.Response, error) {
+	return confNames(b.pools), nil, nil
+}
+
+func (b *backupConfig) GetPool(name string) (*stingray.Pool, *http.Response, error) {
+	conf, ok := b.pools[name]
+	if !ok {
+		return nil, nil, fmt.Errorf("pool %s not found in backup", name)
+	}
+
+	r := stingray.NewPool(name)
+
+	// Draining nodes are also listed in "nodes", disabled ones are not.
+	var order []string
+	states := make(map[string]string)
+	for _, list := range []struct{ key, state string }{
+		{"nodes", "active"},
+		{"draining", "draining"},
+		{"disabled", "disabled"},
+	} {
+		for _, node := range conf.list(list.key) {
+			if _, ok := states[node]; !ok {
+				order = append(order, node)
+			}
+			states[node] = list.state
+		}
+	}
+
+	nodes := stingray.NodesTable{}
+	for _, node := range order {
+		node, state := node, states[node]
+		nodes = append(nodes, stingray.Node{Node: &node, State: &state})
+	}
+	r.Basic.NodesTable = &nodes
+	maxReplyTime := conf.int("max_reply_time", 30)
+	r.Connection.MaxReplyTime = &maxReplyTime
+
+	return r, nil, nil
+}
+
+func confNames(objects map[string]confFile) []string {
+	names := make([]string, 0, len(objects))
+	for name := range objects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
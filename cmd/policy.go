@@ -0,0 +1,134 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gobwas/glob"
+	"github.com/spf13/viper"
+)
+
+// force is set with --force to change objects protected by policy.
+var force bool
+
+// policy holds the guardrails from the policy section of the config file.
+// A profile can override each setting with its own policy section:
+//
+//	policy:
+//	  protected: ["billing-*"]
+//	  maxChanges: 10
+//	profiles:
+//	  production:
+//	    policy:
+//	      readOnly: true
+type policy struct {
+	Profile    string
+	Protected  []string
+	MaxChanges int
+	ReadOnly   bool
+}
+
+func currentPolicy() policy {
+	profile := viper.GetString("profile")
+
+	return policy{
+		Profile:    profile,
+		Protected:  viper.GetStringSlice(profileKey(profile, "policy.protected")),
+		MaxChanges: viper.GetInt(profileKey(profile, "policy.maxChanges")),
+		ReadOnly:   viper.GetBool(profileKey(profile, "policy.readOnly")),
+	}
+}
+
+// checkWrite returns an error if the policy does not allow any changes.
+func (p policy) checkWrite() error {
+	if !p.ReadOnly {
+		return nil
+	}
+
+	if p.Profile != "" {
+		return fmt.Errorf("Blocked by policy: profile %s is read-only (policy.readOnly)", p.Profile)
+	}
+
+	return fmt.Errorf("Blocked by policy: %s is read-only (policy.readOnly)", currentConnection().URL)
+}
+
+// check returns an error explaining which policy forbids changes.
+func (p policy) check(changes []change) error {
+	if err := p.checkWrite(); err != nil {
+		return err
+	}
+
+	objects := make(map[string]bool)
+	var protected []string
+
+	for _, c := range changes {
+		object := c.Kind + " " + c.Name
+		if objects[object] {
+			continue
+		}
+		objects[object] = true
+
+		for _, pattern := range p.Protected {
+			g, err := glob.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("Invalid policy.protected pattern %q: %v", pattern, err)
+			}
+
+			if g.Match(c.Name) {
+				protected = append(protected, fmt.Sprintf("%s (matches %q)", object, pattern))
+				break
+			}
+		}
+	}
+
+	if len(protected) > 0 && !force {
+		return fmt.Errorf("Blocked by policy: protected objects need --force (policy.protected):\n\t%s", strings.Join(protected, "\n\t"))
+	}
+
+	if p.MaxChanges > 0 && len(objects) > p.MaxChanges {
+		return fmt.Errorf("Blocked by policy: %d objects would be changed, at most %d are allowed per invocation (policy.maxChanges)", len(objects), p.MaxChanges)
+	}
+
+	return nil
+}
+
+// enforcePolicy exits if the policy or a change freeze forbids changes.
+func enforcePolicy(changes []change) {
+	if err := currentPolicy().check(changes); err != nil {
+		log.Fatal(err)
+	}
+
+	enforceFreeze(changeTime())
+}
+
+// enforceWrite is enforcePolicy for commands that don't change individual
+// objects, e.g. backup restore.
+func enforceWrite() {
+	if err := currentPolicy().checkWrite(); err != nil {
+		log.Fatal(err)
+	}
+
+	enforceFreeze(time.Now())
+}
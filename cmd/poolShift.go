@@ -0,0 +1,410 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gobwas/glob"
+	"github.com/martinlindner/go-vtm"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	shiftGroups []string
+	shiftSteps  []string
+)
+
+// poolShiftCmd represents the pool shift command
+var poolShiftCmd = &cobra.Command{
+	Use:   "shift [pool]",
+	Short: "Shift traffic of [pool] between node groups by adjusting node weights",
+	Long: `Shift traffic of [pool] between groups of nodes by adjusting node weights,
+e.g. --group new=10 --group old=90. A group is a list of node globs from the
+nodeGroups section of the config file, or a node glob itself:
+
+  nodeGroups:
+    new: ["10.0.1.*"]
+    old: ["10.0.0.*"]
+
+With --steps 10,25,50,100 the weights move from the current distribution to
+the target in steps, each given as percent of the way. Before the first step
+the pool is observed for --pause, and after each step again; if its error
+ratio rises or traffic drops beyond the canary limits, the original weights
+are restored. Groups with 0% get their nodes drained, draining nodes of a
+group with traffic are reactivated. Disabled nodes are left alone.
+
+Weights only take effect if the pool uses a weighted load balancing
+algorithm.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 || len(shiftGroups) == 0 {
+			return errors.New("Missing argument(s)")
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		poolShift(args[0])
+	},
+}
+
+// nodeGroup is a set of nodes of a pool and its target share of the traffic.
+type nodeGroup struct {
+	name     string
+	patterns []glob.Glob
+	target   float64
+	nodes    []int
+}
+
+func (g *nodeGroup) match(node string) bool {
+	for _, p := range g.patterns {
+		if p.Match(node) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseNodeGroups parses NAME=WEIGHT specs. The weights are normalised to
+// percentages.
+func parseNodeGroups(specs []string) ([]*nodeGroup, error) {
+	var groups []*nodeGroup
+	total := 0.0
+
+	for _, spec := range specs {
+		i := strings.LastIndex(spec, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("Invalid group %q, expected NAME=WEIGHT", spec)
+		}
+
+		weight, err := strconv.ParseFloat(spec[i+1:], 64)
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("Invalid weight in group %q", spec)
+		}
+
+		g := &nodeGroup{name: spec[:i], target: weight}
+
+		patterns := viper.GetStringSlice("nodeGroups." + g.name)
+		if len(patterns) == 0 {
+			patterns = []string{g.name}
+		}
+		for _, pattern := range patterns {
+			p, err := glob.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("Group %s: %v", g.name, err)
+			}
+			g.patterns = append(g.patterns, p)
+		}
+
+		groups = append(groups, g)
+		total += weight
+	}
+
+	if total == 0 {
+		return nil, errors.New("All group weights are 0")
+	}
+
+	for _, g := range groups {
+		g.target = g.target * 100 / total
+	}
+
+	return groups, nil
+}
+
+func parseSteps(specs []string) ([]float64, error) {
+	if len(specs) == 0 {
+		return []float64{100}, nil
+	}
+
+	var steps []float64
+	last := 0.0
+	for _, spec := range specs {
+		step, err := strconv.ParseFloat(strings.TrimSuffix(spec, "%"), 64)
+		if err != nil || step <= last || step > 100 {
+			return nil, fmt.Errorf("Invalid step %q, steps have to increase up to 100", spec)
+		}
+		steps = append(steps, step)
+		last = step
+	}
+
+	if last != 100 {
+		steps = append(steps, 100)
+	}
+
+	return steps, nil
+}
+
+func nodeState(n stingray.Node) string {
+	if n.State == nil {
+		return "active"
+	}
+
+	return *n.State
+}
+
+func nodeWeight(n stingray.Node) int {
+	if n.Weight == nil {
+		return 1
+	}
+
+	return *n.Weight
+}
+
+// groupShares returns the current traffic share of each group in percent,
+// based on the weights of its active nodes.
+func groupShares(nodes stingray.NodesTable, groups []*nodeGroup) map[*nodeGroup]float64 {
+	sums := make(map[*nodeGroup]float64)
+	total := 0.0
+	for _, g := range groups {
+		for _, i := range g.nodes {
+			if nodeState(nodes[i]) == "active" {
+				sums[g] += float64(nodeWeight(nodes[i]))
+			}
+		}
+		total += sums[g]
+	}
+
+	shares := make(map[*nodeGroup]float64)
+	for _, g := range groups {
+		if total > 0 {
+			shares[g] = sums[g] * 100 / total
+		}
+	}
+
+	return shares
+}
+
+// weightedNodes returns a copy of nodes with weights giving each group its
+// share. The node with the largest share gets weight 100.
+func weightedNodes(nodes stingray.NodesTable, groups []*nodeGroup, shares map[*nodeGroup]float64) stingray.NodesTable {
+	table := make(stingray.NodesTable, len(nodes))
+	copy(table, nodes)
+
+	max := 0.0
+	for _, g := range groups {
+		if len(g.nodes) > 0 && shares[g]/float64(len(g.nodes)) > max {
+			max = shares[g] / float64(len(g.nodes))
+		}
+	}
+
+	for _, g := range groups {
+		for _, i := range g.nodes {
+			n := table[i]
+
+			state := "active"
+			if shares[g] <= 0 {
+				state = "draining"
+			} else {
+				weight := int(100*shares[g]/float64(len(g.nodes))/max + 0.5)
+				if weight < 1 {
+					weight = 1
+				}
+				n.Weight = &weight
+			}
+			n.State = &state
+
+			table[i] = n
+		}
+	}
+
+	return table
+}
+
+func poolShift(pool string) {
+	if dryRun {
+		fmt.Println(dryRunC)
+	}
+
+	groups, err := parseNodeGroups(shiftGroups)
+	if err != nil {
+		log.Fatal(err)
+	}
+	steps, err := parseSteps(shiftSteps)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(steps) > 1 && (scheduleAt != "" || timebox > 0 || canarySize > 0) {
+		log.Fatal("--at, --for and --canary cannot be combined with --steps")
+	}
+
+	client := initClient()
+	api := initAPIClient()
+
+	fmt.Println("Getting pool", pool, "from", currentConnection().URL)
+	r, resp, err := client.GetPool(pool)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Response:", resp.Status)
+
+	warnUnweighted(api, pool)
+
+	if r.Basic.NodesTable == nil {
+		log.Fatal("Pool ", pool, " has no nodes")
+	}
+	nodes := *r.Basic.NodesTable
+
+	for i, n := range nodes {
+		if n.Node == nil || nodeState(n) == "disabled" {
+			continue
+		}
+
+		grouped := false
+		for _, g := range groups {
+			if g.match(*n.Node) {
+				g.nodes = append(g.nodes, i)
+				grouped = true
+				break
+			}
+		}
+		if !grouped {
+			fmt.Print(*n.Node, ":\tnot in any group, weight unchanged\n")
+		}
+	}
+
+	for _, g := range groups {
+		if len(g.nodes) == 0 {
+			log.Fatal("Group ", g.name, " matches no node of pool ", pool)
+		}
+	}
+
+	current := groupShares(nodes, groups)
+
+	var changes []change
+	prev := nodes
+	for i, step := range steps {
+		shares := make(map[*nodeGroup]float64)
+		var desc []string
+		for _, g := range groups {
+			shares[g] = current[g] + (g.target-current[g])*step/100
+			desc = append(desc, fmt.Sprintf("%s %.0f%% (%d nodes)", g.name, shares[g], len(g.nodes)))
+		}
+
+		next := weightedNodes(nodes, groups, shares)
+		fmt.Print("step ", i+1, "/", len(steps), ":\t", strings.Join(desc, ", "), "\n")
+
+		before, err := json.Marshal(prev)
+		if err != nil {
+			log.Fatal(err)
+		}
+		after, err := json.Marshal(next)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		c := change{Kind: "pool", Name: pool, Field: "basic.nodes_table", Before: before, After: after}
+		if i == 0 {
+			c.object = r
+		}
+		changes = append(changes, c)
+		prev = next
+	}
+
+	if len(changes) == 1 {
+		applyChanges(&client, changes)
+		return
+	}
+
+	shiftStepwise(&client, api, pool, changes)
+}
+
+// shiftStepwise applies one change per step, checking the pool's health
+// against the baseline after each step and restoring the original weights if
+// it degrades.
+func shiftStepwise(client *stingray.Client, api *apiClient, pool string, changes []change) {
+	enforcePolicy(changes)
+
+	if dryRun {
+		return
+	}
+
+	if !confirmChanges(changes) {
+		log.Fatal("Aborted, nothing changed")
+	}
+
+	audit := openAudit()
+	defer audit.Close()
+
+	entry := newJournalEntry()
+	objects := []objectRef{{"pool", pool}}
+
+	fmt.Println("Measuring baseline for", canaryPause)
+	baseline, err := observe(api, objects)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for i := range changes {
+		if err := applyBatch(client, changes[i:i+1], entry, audit); err != nil {
+			revertEntry(client, entry, audit)
+			log.Fatal(err)
+		}
+
+		fmt.Print("Step ", i+1, "/", len(changes), " applied, observing for ", canaryPause, "\n")
+		after, err := observe(api, objects)
+		if err != nil {
+			revertEntry(client, entry, audit)
+			log.Fatal(err)
+		}
+
+		if !healthy(objects, baseline, after) {
+			if !revertEntry(client, entry, audit) {
+				log.Fatal("Health check failed, not every change was reverted")
+			}
+			log.Fatal("Health check failed, original weights restored")
+		}
+	}
+
+	entry.save()
+}
+
+// warnUnweighted warns if pool does not use a weighted load balancing
+// algorithm.
+func warnUnweighted(api *apiClient, pool string) {
+	var doc struct {
+		Properties struct {
+			LoadBalancing struct {
+				Algorithm string `json:"algorithm"`
+			} `json:"load_balancing"`
+		} `json:"properties"`
+	}
+
+	err := api.getJSON("config/active/pools/"+url.PathEscape(pool), &doc)
+	if algorithm := doc.Properties.LoadBalancing.Algorithm; err == nil && !strings.HasPrefix(algorithm, "weighted") {
+		fmt.Println("Warning: pool", pool, "uses", algorithm, "load balancing, node weights only take effect with a weighted algorithm")
+	}
+}
+
+func init() {
+	poolCmd.AddCommand(poolShiftCmd)
+
+	poolShiftCmd.Flags().StringArrayVar(&shiftGroups, "group", nil, "Node group and its target weight, NAME=WEIGHT. Can be given multiple times.")
+	poolShiftCmd.Flags().StringSliceVar(&shiftSteps, "steps", nil, "Shift in steps, given as percent of the way to the target, e.g. 10,25,50,100.")
+	addChangeFlags(poolShiftCmd)
+}
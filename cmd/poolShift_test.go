@@ -0,0 +1,152 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/martinlindner/go-vtm"
+)
+
+func TestParseNodeGroups(t *testing.T) {
+	tests := []struct {
+		specs []string
+		want  []float64
+	}{
+		{[]string{"blue=50", "green=50"}, []float64{50, 50}},
+		{[]string{"blue=1", "green=3"}, []float64{25, 75}},
+		{[]string{"blue=30", "green=30"}, []float64{50, 50}},
+		{[]string{"blue=200", "green=100", "red=100"}, []float64{50, 25, 25}},
+		{[]string{"blue=0", "green=10"}, []float64{0, 100}},
+		{[]string{"10.0.0.*=1.5", "10.0.1.*=0.5"}, []float64{75, 25}},
+	}
+
+	for _, test := range tests {
+		groups, err := parseNodeGroups(test.specs)
+		if err != nil {
+			t.Errorf("%v: %v", test.specs, err)
+			continue
+		}
+
+		var got []float64
+		for _, g := range groups {
+			got = append(got, g.target)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v: got targets %v, want %v", test.specs, got, test.want)
+		}
+	}
+
+	for _, specs := range [][]string{{"blue"}, {"blue=x"}, {"blue=-1"}, {"blue=0", "green=0"}} {
+		if _, err := parseNodeGroups(specs); err == nil {
+			t.Errorf("%v: no error", specs)
+		}
+	}
+}
+
+func TestParseSteps(t *testing.T) {
+	tests := []struct {
+		specs []string
+		want  []float64
+	}{
+		{nil, []float64{100}},
+		{[]string{"100"}, []float64{100}},
+		{[]string{"25", "50"}, []float64{25, 50, 100}},
+		{[]string{"10%", "100%"}, []float64{10, 100}},
+		{[]string{"33.3", "66.6"}, []float64{33.3, 66.6, 100}},
+	}
+
+	for _, test := range tests {
+		got, err := parseSteps(test.specs)
+		if err != nil {
+			t.Errorf("%v: %v", test.specs, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v: got %v, want %v", test.specs, got, test.want)
+		}
+	}
+
+	for _, specs := range [][]string{{"0"}, {"50", "25"}, {"50", "50"}, {"150"}, {"x"}} {
+		if _, err := parseSteps(specs); err == nil {
+			t.Errorf("%v: no error", specs)
+		}
+	}
+}
+
+func TestWeightedNodes(t *testing.T) {
+	names := []string{"b1", "b2", "g1", "g2", "g3"}
+	nodes := make(stingray.NodesTable, len(names))
+	for i := range names {
+		nodes[i].Node = &names[i]
+	}
+	blue := &nodeGroup{name: "blue", nodes: []int{0, 1}}
+	green := &nodeGroup{name: "green", nodes: []int{2, 3, 4}}
+	groups := []*nodeGroup{blue, green}
+
+	tests := []struct {
+		shares  []float64
+		weights []int
+		states  []string
+	}{
+		{[]float64{50, 50}, []int{100, 100, 67, 67, 67}, nil},
+		{[]float64{25, 75}, []int{50, 50, 100, 100, 100}, nil},
+		// Shares that don't sum to 100 give the same ratio.
+		{[]float64{10, 30}, []int{50, 50, 100, 100, 100}, nil},
+		{[]float64{2, 1}, []int{100, 100, 33, 33, 33}, nil},
+		{[]float64{0.1, 99.9}, []int{1, 1, 100, 100, 100}, nil},
+		{[]float64{0, 100}, []int{0, 0, 100, 100, 100}, []string{"draining", "draining"}},
+	}
+
+	for _, test := range tests {
+		shares := map[*nodeGroup]float64{blue: test.shares[0], green: test.shares[1]}
+		table := weightedNodes(nodes, groups, shares)
+
+		for i, n := range table {
+			state := nodeState(n)
+			want := "active"
+			if i < len(test.states) {
+				want = test.states[i]
+			}
+			if state != want {
+				t.Errorf("%v: %s is %s, want %s", test.shares, names[i], state, want)
+			}
+			if state == "active" && nodeWeight(n) != test.weights[i] {
+				t.Errorf("%v: %s has weight %d, want %d", test.shares, names[i], nodeWeight(n), test.weights[i])
+			}
+		}
+
+		// The weights give each group its share of the total.
+		got := groupShares(table, groups)
+		total := test.shares[0] + test.shares[1]
+		for i, g := range groups {
+			if want := test.shares[i] * 100 / total; math.Abs(got[g]-want) > 1 {
+				t.Errorf("%v: %s gets %.1f%%, want %.1f%%", test.shares, g.name, got[g], want)
+			}
+		}
+	}
+
+	if nodes[0].Weight != nil || nodes[0].State != nil {
+		t.Error("weightedNodes changed its input")
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gobwas/glob"
+	"github.com/spf13/cobra"
+)
+
+// poolStatsCmd represents the pool stats command
+var poolStatsCmd = &cobra.Command{
+	Use:   "stats [pool]",
+	Short: "Show traffic counters of [pool] and its nodes",
+	Long: `Show the state, connections and bytes of the pools matching [pool], and the
+state, connections, response times and errors of each of their nodes, as
+counted by the traffic manager the API URL points to. With --interval the
+counters are read twice and rates per second are shown as well.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("Missing argument(s)")
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		watch(func(out io.Writer) error {
+			return poolStats(out, args[0])
+		})
+	},
+}
+
+type poolRates struct {
+	Conn     float64 `json:"conn"`
+	BytesIn  float64 `json:"bytes_in"`
+	BytesOut float64 `json:"bytes_out"`
+}
+
+type poolNodeRates struct {
+	Conn   float64 `json:"conn"`
+	Errors float64 `json:"errors"`
+}
+
+type poolNodeStatsRow struct {
+	Node string `json:"node"`
+	poolNodeStatistics
+	Rates *poolNodeRates `json:"rates,omitempty"`
+}
+
+type poolStatsRow struct {
+	Name string `json:"name"`
+	poolStatistics
+	Rates *poolRates         `json:"rates,omitempty"`
+	Nodes []poolNodeStatsRow `json:"nodes"`
+}
+
+// samplePoolStats reads the statistics of each pool and its nodes. If
+// prev is nil, the configured nodes of each pool are looked up, otherwise
+// the nodes of the previous sample are read again.
+func samplePoolStats(api *apiClient, names []string, prev []poolStatsRow) ([]poolStatsRow, time.Time, error) {
+	rows := make([]poolStatsRow, 0, len(names))
+	for i, name := range names {
+		s, err := api.poolStatistics(name)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		row := poolStatsRow{Name: name, poolStatistics: s}
+
+		var nodes []string
+		if prev != nil {
+			for _, n := range prev[i].Nodes {
+				nodes = append(nodes, n.Node)
+			}
+		} else if nodes, err = api.poolNodes(name); err != nil {
+			return nil, time.Time{}, err
+		}
+
+		for _, node := range nodes {
+			n, err := api.poolNodeStatistics(name, node)
+			if err != nil {
+				return nil, time.Time{}, err
+			}
+			row.Nodes = append(row.Nodes, poolNodeStatsRow{Node: node, poolNodeStatistics: n})
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, time.Now(), nil
+}
+
+func poolStats(out io.Writer, targetPool string) error {
+	poolGlob := glob.MustCompile(targetPool)
+	api := initAPIClient()
+
+	progress("Getting pool statistics from", api.conn.URL)
+	names, err := api.statisticsNames("pools", poolGlob)
+	if err != nil {
+		return err
+	}
+	rows, at, err := samplePoolStats(api, names, nil)
+	if err != nil {
+		return err
+	}
+
+	if statsInterval > 0 {
+		progress("Measuring rates for", statsInterval)
+		time.Sleep(statsInterval)
+
+		after, end, err := samplePoolStats(api, names, rows)
+		if err != nil {
+			return err
+		}
+		d := end.Sub(at)
+		for i, a := range rows {
+			b := &after[i]
+			b.Rates = &poolRates{
+				Conn:     perSecond(a.TotalConn, b.TotalConn, d),
+				BytesIn:  perSecond(a.BytesIn, b.BytesIn, d),
+				BytesOut: perSecond(a.BytesOut, b.BytesOut, d),
+			}
+			for j, n := range a.Nodes {
+				m := &b.Nodes[j]
+				m.Rates = &poolNodeRates{
+					Conn:   perSecond(n.TotalConn, m.TotalConn, d),
+					Errors: perSecond(n.Errors+n.Failures, m.Errors+m.Failures, d),
+				}
+			}
+		}
+		rows = after
+	}
+
+	if jsonOutput() {
+		fprintJSON(out, rows)
+		return nil
+	}
+
+	w := new(tabwriter.Writer)
+	w.Init(out, 0, 5, 2, ' ', 0)
+
+	for _, r := range rows {
+		fmt.Fprint(w, r.Name, ":\t", r.State, "\t", r.TotalConn, " total\t", r.QueueTimeouts, " queue timeouts")
+		if r.Rates != nil {
+			fmt.Fprintf(w, "\t%.1f conn/s", r.Rates.Conn)
+		}
+		fmt.Fprintln(w)
+
+		for _, n := range r.Nodes {
+			fmt.Fprint(w, "\t- ", n.Node, ":\t", n.State, "\t", n.CurrentConn, " current\t", n.TotalConn, " total\t", n.ResponseMean, "ms mean\t", n.ResponseMax, "ms max\t", n.Errors+n.Failures, " errors")
+			if n.Rates != nil {
+				fmt.Fprintf(w, "\t%.1f conn/s\t%.2f err/s", n.Rates.Conn, n.Rates.Errors)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+	return w.Flush()
+}
+
+func init() {
+	poolCmd.AddCommand(poolStatsCmd)
+
+	poolStatsCmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table or json).")
+	poolStatsCmd.Flags().DurationVar(&statsInterval, "interval", 0, "Read the counters twice this far apart and show rates.")
+	poolStatsCmd.Flags().DurationVar(&watchInterval, "watch", 0, "Re-run at this interval, highlighting changed lines.")
+}
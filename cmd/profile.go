@@ -0,0 +1,120 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/martinlindner/go-vtm"
+	"github.com/spf13/viper"
+)
+
+// connection holds the settings needed to talk to a single vTM cluster.
+type connection struct {
+	URL     string
+	User    string
+	Pass    string
+	Version string
+}
+
+// profileConnection returns the connection settings of the named profile in
+// the config file. Settings the profile does not define fall back to the
+// global vtmAPIUrl/vtmAPIUser/vtmAPIPass/vtmAPIVersion values.
+func profileConnection(profile string) connection {
+	get := func(key string) string {
+		return viper.GetString(profileKey(profile, key))
+	}
+
+	return connection{
+		URL:     get("vtmAPIUrl"),
+		User:    get("vtmAPIUser"),
+		Pass:    get("vtmAPIPass"),
+		Version: get("vtmAPIVersion"),
+	}
+}
+
+// profileKey returns the config key of a setting of profile, which is the
+// global key unless the profile overrides it.
+func profileKey(profile, key string) string {
+	if profile != "" && viper.IsSet("profiles."+profile+"."+key) {
+		return "profiles." + profile + "." + key
+	}
+
+	return key
+}
+
+// currentConnection returns the connection settings selected by --profile
+// and the connection flags.
+func currentConnection() connection {
+	conn, err := resolveConnection()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return conn
+}
+
+// resolveConnection is currentConnection returning an error for an unknown
+// profile.
+func resolveConnection() (connection, error) {
+	profile := viper.GetString("profile")
+	if profile != "" && !isProfile(profile) {
+		return connection{}, fmt.Errorf("Unknown profile: %s", profile)
+	}
+
+	conn := profileConnection(profile)
+
+	// Flags given on the command line win over the profile.
+	flags := RootCmd.PersistentFlags()
+	for key, value := range map[string]*string{
+		"vtmAPIUrl":     &conn.URL,
+		"vtmAPIUser":    &conn.User,
+		"vtmAPIPass":    &conn.Pass,
+		"vtmAPIVersion": &conn.Version,
+	} {
+		if flags.Changed(key) {
+			*value = viper.GetString(key)
+		}
+	}
+
+	return conn, nil
+}
+
+// isProfile reports whether name is a profile defined in the config file.
+func isProfile(name string) bool {
+	return viper.IsSet("profiles." + name)
+}
+
+func newHTTPClient() *http.Client {
+	// TODO: make this configurable
+	tr := &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}
+
+	return &http.Client{Transport: tr}
+}
+
+func newClient(conn connection) stingray.Client {
+	return *stingray.NewClient(newHTTPClient(), conn.URL, conn.User, conn.Pass)
+}
@@ -0,0 +1,207 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	recordInterval time.Duration
+	recordOut      string
+)
+
+// recordCmd represents the record command
+var recordCmd = &cobra.Command{
+	Use:   "record",
+	Short: "Record vserver and pool statistics to CSV files",
+	Long: `Sample the statistics of all vservers and pools every --interval and append
+connection, byte and error rates to CSV files, one per day: --out stats.csv
+writes stats-20171101.csv, stats-20171102.csv and so on. The recordings are
+always CSV, there is no SQLite output: --out stats.db writes the same
+stats-20171101.csv files. The error rate of pools counts queue
+timeouts. Runs until interrupted. Summarize the recordings with the report
+command.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		record()
+	},
+}
+
+// recordColumns is the header of recording files. Rates are per second
+// since the previous sample.
+var recordColumns = []string{"time", "kind", "name", "current_conn", "conn_rate", "bytes_in_rate", "bytes_out_rate", "error_rate"}
+
+// recordCounters are the counters of an object that are recorded.
+type recordCounters struct {
+	current                          *int64
+	conns, bytesIn, bytesOut, errors int64
+}
+
+// recordFile returns the CSV file the samples of the day of t go to. The
+// extension of out is replaced by .csv.
+func recordFile(out string, t time.Time) string {
+	return strings.TrimSuffix(out, filepath.Ext(out)) + "-" + t.Format("20060102") + ".csv"
+}
+
+// recordFiles returns the recording files of out in time order.
+func recordFiles(out string) ([]string, error) {
+	return filepath.Glob(strings.TrimSuffix(out, filepath.Ext(out)) + "-[0-9]*.csv")
+}
+
+// sampleRecord reads the counters of all vservers and pools.
+func sampleRecord(api *apiClient) (map[objectRef]recordCounters, time.Time, error) {
+	counters := make(map[objectRef]recordCounters)
+
+	vservers, err := api.children("status/local_tm/statistics/virtual_servers")
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	for _, name := range vservers {
+		s, err := api.vserverStatistics(name)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		current := s.CurrentConn
+		counters[objectRef{"vserver", name}] = recordCounters{&current, s.TotalConn, s.BytesIn, s.BytesOut, s.ConnectionErrors + s.ConnectionFailures}
+	}
+
+	pools, err := api.children("status/local_tm/statistics/pools")
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	for _, name := range pools {
+		s, err := api.poolStatistics(name)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		counters[objectRef{"pool", name}] = recordCounters{nil, s.TotalConn, s.BytesIn, s.BytesOut, s.QueueTimeouts}
+	}
+
+	return counters, time.Now(), nil
+}
+
+// recordRows returns the CSV rows of the rates between two samples. Objects
+// that are new or whose counters were reset are left out.
+func recordRows(before, after map[objectRef]recordCounters, d time.Duration, at time.Time) [][]string {
+	var objects []objectRef
+	for o := range after {
+		objects = append(objects, o)
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		if objects[i].kind != objects[j].kind {
+			return objects[i].kind > objects[j].kind
+		}
+		return objects[i].name < objects[j].name
+	})
+
+	rate := func(a, b int64) string {
+		return strconv.FormatFloat(perSecond(a, b, d), 'f', 3, 64)
+	}
+
+	var rows [][]string
+	for _, o := range objects {
+		a, ok := before[o]
+		b := after[o]
+		if !ok || b.conns < a.conns || b.bytesIn < a.bytesIn || b.bytesOut < a.bytesOut || b.errors < a.errors {
+			continue
+		}
+
+		current := ""
+		if b.current != nil {
+			current = strconv.FormatInt(*b.current, 10)
+		}
+
+		rows = append(rows, []string{
+			at.Format(time.RFC3339), o.kind, o.name, current,
+			rate(a.conns, b.conns), rate(a.bytesIn, b.bytesIn), rate(a.bytesOut, b.bytesOut), rate(a.errors, b.errors),
+		})
+	}
+
+	return rows
+}
+
+// appendRecords appends rows to the recording file at path, writing the
+// header first if the file is new.
+func appendRecords(path string, rows [][]string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(f)
+	if info.Size() == 0 {
+		w.Write(recordColumns)
+	}
+
+	return w.WriteAll(rows)
+}
+
+func record() {
+	api := initAPIClient()
+
+	before, at, err := sampleRecord(api)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println("Recording statistics of", api.conn.URL, "every", recordInterval, "to", recordFile(recordOut, at))
+
+	ticker := time.NewTicker(recordInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		after, end, err := sampleRecord(api)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		rows := recordRows(before, after, end.Sub(at), end)
+		if err := appendRecords(recordFile(recordOut, end), rows); err != nil {
+			log.Fatal(err)
+		}
+
+		before, at = after, end
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(recordCmd)
+
+	recordCmd.Flags().DurationVar(&recordInterval, "interval", 10*time.Second, "Sampling interval.")
+	recordCmd.Flags().StringVar(&recordOut, "out", "stats.csv", "Recording CSV file name, the date and the .csv extension replace its extension.")
+}
@@ -0,0 +1,93 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRecordRows(t *testing.T) {
+	at := time.Date(2017, 11, 1, 2, 0, 0, 0, time.UTC)
+	current := int64(3)
+
+	web := objectRef{"vserver", "web"}
+	api := objectRef{"vserver", "api"}
+	pool := objectRef{"pool", "web"}
+
+	tests := []struct {
+		name          string
+		before, after map[objectRef]recordCounters
+		want          [][]string
+	}{
+		{"vserver",
+			map[objectRef]recordCounters{web: {nil, 100, 1000, 2000, 0}},
+			map[objectRef]recordCounters{web: {&current, 150, 2000, 4000, 5}},
+			[][]string{{"2017-11-01T02:00:00Z", "vserver", "web", "3", "5.000", "100.000", "200.000", "0.500"}}},
+		{"pool without current connections",
+			map[objectRef]recordCounters{pool: {nil, 0, 0, 0, 0}},
+			map[objectRef]recordCounters{pool: {nil, 10, 0, 0, 1}},
+			[][]string{{"2017-11-01T02:00:00Z", "pool", "web", "", "1.000", "0.000", "0.000", "0.100"}}},
+		{"new object",
+			map[objectRef]recordCounters{},
+			map[objectRef]recordCounters{web: {&current, 150, 2000, 4000, 5}},
+			nil},
+		{"counter reset",
+			map[objectRef]recordCounters{web: {nil, 100, 1000, 2000, 0}},
+			map[objectRef]recordCounters{web: {&current, 10, 2000, 4000, 5}},
+			nil},
+		{"vservers first, by name",
+			map[objectRef]recordCounters{web: {}, api: {}, pool: {}},
+			map[objectRef]recordCounters{web: {}, api: {}, pool: {}},
+			[][]string{
+				{"2017-11-01T02:00:00Z", "vserver", "api", "", "0.000", "0.000", "0.000", "0.000"},
+				{"2017-11-01T02:00:00Z", "vserver", "web", "", "0.000", "0.000", "0.000", "0.000"},
+				{"2017-11-01T02:00:00Z", "pool", "web", "", "0.000", "0.000", "0.000", "0.000"},
+			}},
+	}
+
+	for _, test := range tests {
+		got := recordRows(test.before, test.after, 10*time.Second, at)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestRecordFile(t *testing.T) {
+	at := time.Date(2017, 11, 1, 2, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		out, want string
+	}{
+		{"stats.csv", "stats-20171101.csv"},
+		{"stats.db", "stats-20171101.csv"},
+		{"stats", "stats-20171101.csv"},
+		{"/var/lib/vtm/stats.csv", "/var/lib/vtm/stats-20171101.csv"},
+	}
+
+	for _, test := range tests {
+		if got := recordFile(test.out, at); got != test.want {
+			t.Errorf("%s: got %s, want %s", test.out, got, test.want)
+		}
+	}
+}
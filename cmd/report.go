@@ -0,0 +1,231 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gobwas/glob"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reportIn   string
+	reportFrom string
+	reportTo   string
+)
+
+// reportCmd represents the report command
+var reportCmd = &cobra.Command{
+	Use:   "report [name]",
+	Short: "Summarize recorded statistics of vservers and pools matching [name]",
+	Long: `Summarize the statistics written by the record command for the vservers and
+pools matching [name] (default all): number of samples, average, median,
+95th and 99th percentile and peak of each rate, optionally limited to the
+time range given by --from and --to.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		target := "*"
+		if len(args) > 0 {
+			target = args[0]
+		}
+
+		report(target)
+	},
+}
+
+// reportSummary summarizes the samples of one metric of an object.
+type reportSummary struct {
+	Kind    string  `json:"kind"`
+	Name    string  `json:"name"`
+	Metric  string  `json:"metric"`
+	Samples int     `json:"samples"`
+	Avg     float64 `json:"avg"`
+	P50     float64 `json:"p50"`
+	P95     float64 `json:"p95"`
+	P99     float64 `json:"p99"`
+	Max     float64 `json:"max"`
+}
+
+// percentile returns the nearest-rank percentile p of sorted values.
+func percentile(sorted []float64, p float64) float64 {
+	i := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+
+	return sorted[i]
+}
+
+func summarize(kind, name, metric string, values []float64) reportSummary {
+	sort.Float64s(values)
+
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+
+	return reportSummary{
+		Kind:    kind,
+		Name:    name,
+		Metric:  metric,
+		Samples: len(values),
+		Avg:     sum / float64(len(values)),
+		P50:     percentile(values, 50),
+		P95:     percentile(values, 95),
+		P99:     percentile(values, 99),
+		Max:     values[len(values)-1],
+	}
+}
+
+// readRecords calls fn with each data row of the recording file at path.
+func readRecords(path string, fn func(row []string) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = len(recordColumns)
+
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+
+		if row[0] == recordColumns[0] {
+			continue
+		}
+		if err := fn(row); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+	}
+}
+
+func report(target string) {
+	nameGlob := glob.MustCompile(target)
+
+	var from, to time.Time
+	var err error
+	if reportFrom != "" {
+		if from, err = parseLocalTime(reportFrom); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if reportTo != "" {
+		if to, err = parseLocalTime(reportTo); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	files, err := recordFiles(reportIn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(files) == 0 {
+		log.Fatal("No recordings found for ", reportIn)
+	}
+
+	type key struct{ kind, name, metric string }
+	values := make(map[key][]float64)
+	var keys []key
+
+	for _, file := range files {
+		err := readRecords(file, func(row []string) error {
+			t, err := time.Parse(time.RFC3339, row[0])
+			if err != nil {
+				return err
+			}
+			if (!from.IsZero() && t.Before(from)) || (!to.IsZero() && !t.Before(to)) || !nameGlob.Match(row[2]) {
+				return nil
+			}
+
+			for i, metric := range recordColumns[3:] {
+				field := row[3+i]
+				if field == "" {
+					continue
+				}
+
+				v, err := strconv.ParseFloat(field, 64)
+				if err != nil {
+					return err
+				}
+
+				k := key{row[1], row[2], metric}
+				if _, ok := values[k]; !ok {
+					keys = append(keys, k)
+				}
+				values[k] = append(values[k], v)
+			}
+
+			return nil
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		if keys[i].kind != keys[j].kind {
+			return keys[i].kind > keys[j].kind
+		}
+		return keys[i].name < keys[j].name
+	})
+
+	summaries := make([]reportSummary, 0, len(keys))
+	for _, k := range keys {
+		summaries = append(summaries, summarize(k.kind, k.name, k.metric, values[k]))
+	}
+
+	if jsonOutput() {
+		printJSON(summaries)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tNAME\tMETRIC\tSAMPLES\tAVG\tP50\tP95\tP99\tMAX")
+	for _, s := range summaries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%.2f\t%.2f\t%.2f\t%.2f\t%.2f\n", s.Kind, s.Name, s.Metric, s.Samples, s.Avg, s.P50, s.P95, s.P99, s.Max)
+	}
+	w.Flush()
+}
+
+func init() {
+	RootCmd.AddCommand(reportCmd)
+
+	reportCmd.Flags().StringVar(&reportIn, "in", "stats.csv", "Recording file name as given to record --out.")
+	reportCmd.Flags().StringVar(&reportFrom, "from", "", "Only samples from this time on, e.g. 2017-11-01.")
+	reportCmd.Flags().StringVar(&reportTo, "to", "", "Only samples before this time.")
+	reportCmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table or json).")
+}
@@ -0,0 +1,71 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPercentile(t *testing.T) {
+	tests := []struct {
+		name   string
+		sorted []float64
+		p      float64
+		want   float64
+	}{
+		{"median of ten", []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, 50, 5},
+		{"95th of ten", []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, 95, 10},
+		{"99th of ten", []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, 99, 10},
+		{"exact rank", []float64{1, 2, 3, 4}, 25, 1},
+		{"median of even count", []float64{1, 2, 3, 4}, 50, 2},
+		{"zero percentile", []float64{1, 2, 3}, 0, 1},
+		{"single value", []float64{7}, 99, 7},
+	}
+
+	for _, test := range tests {
+		if got := percentile(test.sorted, test.p); got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		want   reportSummary
+	}{
+		{"unsorted", []float64{3, 1, 2},
+			reportSummary{"vserver", "web", "conn_rate", 3, 2, 2, 3, 3, 3}},
+		{"single sample", []float64{5},
+			reportSummary{"vserver", "web", "conn_rate", 1, 5, 5, 5, 5, 5}},
+		{"peak", []float64{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 21},
+			reportSummary{"vserver", "web", "conn_rate", 20, 2, 1, 1, 21, 21}},
+	}
+
+	for _, test := range tests {
+		got := summarize("vserver", "web", "conn_rate", test.values)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
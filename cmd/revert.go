@@ -0,0 +1,120 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	revertList bool
+	revertNow  bool
+)
+
+// revertCmd represents the revert command
+var revertCmd = &cobra.Command{
+	Use:   "revert [id]",
+	Short: "Resume the revert of an interrupted time-boxed change",
+	Long: `Resume the revert of a change made with --for whose go-vtm-cli process was
+killed. It waits until the recorded end of the change, unless --now is given,
+and then restores the previous values. Without [id] the latest pending revert
+on the current cluster is resumed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		id := ""
+		if len(args) > 0 {
+			id = args[0]
+		}
+
+		revert(id)
+	},
+}
+
+func revert(id string) {
+	if dryRun && !revertList {
+		fmt.Println(dryRunC)
+	}
+
+	reverts, err := loadRevertFiles()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if revertList {
+		w := new(tabwriter.Writer)
+		w.Init(os.Stdout, 0, 5, 2, ' ', 0)
+		for _, f := range reverts {
+			fmt.Fprint(w, f.Entry.ID, "\t", f.Until.Format("2006-01-02 15:04:05"), "\t", f.Entry.URL, "\t", f.Entry.Command, "\n")
+		}
+		if len(reverts) == 0 {
+			fmt.Fprint(w, "(no pending reverts)\n")
+		}
+		w.Flush()
+		return
+	}
+
+	url := currentConnection().URL
+
+	var pending *revertFile
+	for i := len(reverts) - 1; i >= 0; i-- {
+		f := reverts[i]
+		if f.Entry.ID == id || (id == "" && f.Entry.URL == url) {
+			pending = f
+			break
+		}
+	}
+
+	if pending == nil {
+		log.Fatal("No pending revert")
+	}
+	if pending.Entry.URL != url {
+		log.Fatalf("Change %s was made on %s, select that cluster with --profile or --vtmAPIUrl", pending.Entry.ID, pending.Entry.URL)
+	}
+
+	fmt.Println("Reverting", pending.Entry.ID+":", pending.Entry.Command)
+	if dryRun {
+		return
+	}
+
+	client := initClient()
+	audit := openAudit()
+	defer audit.Close()
+
+	if !revertNow && waitUntil(pending.Until, "Reverting", "revert now") {
+		fmt.Println("Interrupted, reverting now")
+	}
+
+	if !revertEntry(&client, pending.Entry, audit) {
+		log.Fatal("Not every change was reverted, keeping the pending revert of ", pending.Entry.ID)
+	}
+	pending.remove()
+}
+
+func init() {
+	RootCmd.AddCommand(revertCmd)
+
+	revertCmd.Flags().BoolVar(&revertList, "list", false, "List pending reverts.")
+	revertCmd.Flags().BoolVar(&revertNow, "now", false, "Revert immediately instead of waiting for the end of the change.")
+}
@@ -21,9 +21,8 @@
 package cmd
 
 import (
-	"crypto/tls"
 	"fmt"
-	"net/http"
+	"log"
 	"os"
 
 	"github.com/martinlindner/go-vtm"
@@ -34,11 +33,15 @@ import (
 )
 
 var (
-	cfgFile   string
-	dryRun    bool
-	dryRunC   = ansi.Color("dry-run!", "red+bh")
-	enabledC  = ansi.Color("enabled", "green")
-	disabledC = ansi.Color("disabled", "red")
+	cfgFile    string
+	dryRun     bool
+	dryRunC    = ansi.Color("dry-run!", "red+bh")
+	enabledC   = ansi.Color("enabled", "green")
+	disabledC  = ansi.Color("disabled", "red")
+	healthyC   = ansi.Color("healthy", "green")
+	unhealthyC = ansi.Color("unhealthy", "red+bh")
+	aliveC     = ansi.Color("alive", "green")
+	deadC      = ansi.Color("dead", "red+b")
 )
 
 var RootCmd = &cobra.Command{
@@ -48,7 +51,14 @@ var RootCmd = &cobra.Command{
 }
 
 func Execute() {
-	if err := RootCmd.Execute(); err != nil {
+	if cmd, _, err := RootCmd.Find(os.Args[1:]); err == nil && isCheck(cmd) {
+		checkRunning = true
+	}
+
+	if cmd, err := RootCmd.ExecuteC(); err != nil {
+		if isCheck(cmd) {
+			checkError(cmd, err)
+		}
 		fmt.Println(err)
 		os.Exit(1)
 	}
@@ -62,12 +72,22 @@ func init() {
 	RootCmd.PersistentFlags().String("vtmAPIUrl", "http://localhost:9070/", "vTM API URL.")
 	RootCmd.PersistentFlags().String("vtmAPIUser", "admin", "vTM API user.")
 	RootCmd.PersistentFlags().String("vtmAPIPass", "default", "vTM API password.")
+	RootCmd.PersistentFlags().String("vtmAPIVersion", "3.8", "vTM REST API version used for status, statistics and raw configuration requests.")
+
+	RootCmd.PersistentFlags().String("profile", "", "Use the connection settings of this profile from the config file.")
+
+	RootCmd.PersistentFlags().StringVar(&offlinePath, "offline", "", "Read configuration from a backup archive or extracted config directory instead of the API (get commands only).")
 
 	RootCmd.PersistentFlags().BoolVarP(&dryRun, "dry-run", "d", false, "Don't actually apply any changes.")
+	RootCmd.PersistentFlags().BoolVar(&force, "force", false, "Allow changes to objects protected by policy, or making the last active member of a traffic IP group passive.")
+	RootCmd.PersistentFlags().StringVar(&overrideFreeze, "override-freeze", "", "Apply changes during a change freeze, giving the reason.")
+	RootCmd.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false, "Don't ask for confirmation before applying changes.")
 
 	viper.BindPFlag("vtmAPIUrl", RootCmd.PersistentFlags().Lookup("vtmAPIUrl"))
 	viper.BindPFlag("vtmAPIUser", RootCmd.PersistentFlags().Lookup("vtmAPIUser"))
 	viper.BindPFlag("vtmAPIPass", RootCmd.PersistentFlags().Lookup("vtmAPIPass"))
+	viper.BindPFlag("vtmAPIVersion", RootCmd.PersistentFlags().Lookup("vtmAPIVersion"))
+	viper.BindPFlag("profile", RootCmd.PersistentFlags().Lookup("profile"))
 }
 
 func initConfig() {
@@ -91,21 +111,19 @@ func initConfig() {
 
 	// If a config file is found, read it in.
 	if err := viper.ReadInConfig(); err == nil {
-		fmt.Println("Using config file:", viper.ConfigFileUsed())
+		// Keep JSON and monitoring plugin output parseable.
+		if outputFormat == "json" || checkRunning {
+			fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
+		} else {
+			fmt.Println("Using config file:", viper.ConfigFileUsed())
+		}
 	}
 }
 
 func initClient() stingray.Client {
-	vtmAPIUrl := viper.Get("vtmAPIUrl").(string)
-	vtmAPIUser := viper.Get("vtmAPIUser").(string)
-	vtmAPIPass := viper.Get("vtmAPIPass").(string)
-
-	// To
-	// TODO: make this configurable
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	if offlinePath != "" {
+		log.Fatal("--offline can only be used with read-only commands")
 	}
-	httpclient := &http.Client{Transport: tr}
 
-	return *stingray.NewClient(httpclient, vtmAPIUrl, vtmAPIUser, vtmAPIPass)
+	return newClient(currentConnection())
 }
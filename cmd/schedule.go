@@ -0,0 +1,266 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/martinlindner/go-vtm"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	scheduleAt    string
+	scheduleQueue bool
+)
+
+// scheduleCmd represents the schedule command
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "queued change subcommands",
+	Long: `Manage changes queued with --at TIME --queue. Queued changes are executed
+by a running scheduler command.`,
+}
+
+// scheduledJob is a change queued for later execution by the scheduler.
+type scheduledJob struct {
+	ID             string    `json:"id"`
+	At             time.Time `json:"at"`
+	Profile        string    `json:"profile,omitempty"`
+	URL            string    `json:"url"`
+	Command        string    `json:"command"`
+	Force          bool      `json:"force,omitempty"`
+	OverrideFreeze string    `json:"override_freeze,omitempty"`
+	Status         string    `json:"status"`
+	Error          string    `json:"error,omitempty"`
+	Changes        []change  `json:"changes"`
+	// Drain updates the drain record once the changes are applied.
+	Drain *drainUpdate `json:"drain,omitempty"`
+}
+
+// scheduledTime returns the time given with --at, or the zero time.
+func scheduledTime() time.Time {
+	if scheduleAt == "" {
+		return time.Time{}
+	}
+
+	t, err := parseLocalTime(scheduleAt)
+	if err != nil {
+		log.Fatal("--at: ", err)
+	}
+
+	return t
+}
+
+// checkScheduleFlags rejects --queue without --at, and without a profile the
+// scheduler can read the credentials from.
+func checkScheduleFlags() {
+	if !scheduleQueue {
+		return
+	}
+	if scheduleAt == "" {
+		log.Fatal("--queue can only be used with --at")
+	}
+
+	profile := viper.GetString("profile")
+	if profile == "" {
+		log.Fatal("--queue needs --profile, queued changes read their credentials from the profile")
+	}
+	if currentConnection().URL != profileConnection(profile).URL {
+		log.Fatal("--queue cannot be combined with --vtmAPIUrl, queued changes run against the URL of the profile")
+	}
+}
+
+// changeTime returns when changes will be applied.
+func changeTime() time.Time {
+	if at := scheduledTime(); at.After(time.Now()) {
+		return at
+	}
+
+	return time.Now()
+}
+
+// scheduleChanges waits until --at or queues the changes with --queue. It
+// returns true if the changes should be applied now.
+func scheduleChanges(client *stingray.Client, changes []change) bool {
+	at := scheduledTime()
+	if !at.After(time.Now()) {
+		log.Fatal("--at ", scheduleAt, " is not in the future")
+	}
+
+	if scheduleQueue {
+		if timebox > 0 || canarySize > 0 {
+			log.Fatal("--for and --canary cannot be used for queued changes")
+		}
+
+		queueChanges(at, changes)
+		return false
+	}
+
+	if waitUntil(at, "Applying", "cancel") {
+		log.Fatal("Cancelled, nothing changed")
+	}
+
+	if err := verifyUnchanged(client, changes); err != nil {
+		log.Fatal(err)
+	}
+
+	return true
+}
+
+// verifyUnchanged checks that every object still holds the value the change
+// was computed from.
+func verifyUnchanged(client *stingray.Client, changes []change) error {
+	if err := reload(client, changes); err != nil {
+		return err
+	}
+
+	changed, err := checkInOrder(client, changes, nil)
+	if err != nil {
+		return err
+	}
+
+	if len(changed) > 0 {
+		return fmt.Errorf("Objects were changed since the change was prepared, not applying anything:\n\t%s", strings.Join(changed, "\n\t"))
+	}
+
+	return nil
+}
+
+func queueChanges(at time.Time, changes []change) {
+	// Only the profile and URL are stored, the credentials are read from
+	// the profile when the job runs.
+	job := &scheduledJob{
+		ID:             time.Now().Format("20060102-150405.000"),
+		At:             at,
+		Profile:        viper.GetString("profile"),
+		URL:            currentConnection().URL,
+		Command:        strings.Join(os.Args, " "),
+		Force:          force,
+		OverrideFreeze: overrideFreeze,
+		Status:         "pending",
+		Changes:        changes,
+		Drain:          queuedDrain,
+	}
+
+	if err := job.save(); err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println("Queued", job.ID, "for", at.Format("2006-01-02 15:04:05")+", executed by a running scheduler command")
+}
+
+func (j *scheduledJob) path() (string, error) {
+	dir, err := stateDir("schedule")
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, j.ID+".json"), nil
+}
+
+func (j *scheduledJob) save() error {
+	path, err := j.path()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+func (j *scheduledJob) remove() error {
+	path, err := j.path()
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(path)
+}
+
+// loadJobs returns all queued jobs, earliest first.
+func loadJobs() ([]*scheduledJob, error) {
+	dir, err := stateDir("schedule")
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []*scheduledJob
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		job := new(scheduledJob)
+		if err := json.Unmarshal(data, job); err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].At.Before(jobs[j].At) })
+
+	return jobs, nil
+}
+
+func findJob(id string) *scheduledJob {
+	jobs, err := loadJobs()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, job := range jobs {
+		if job.ID == id {
+			return job
+		}
+	}
+
+	log.Fatal("No scheduled change ", id)
+	return nil
+}
+
+func init() {
+	cobra.OnInitialize(checkScheduleFlags)
+
+	RootCmd.AddCommand(scheduleCmd)
+}
@@ -0,0 +1,68 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// scheduleListCmd represents the schedule list command
+var scheduleListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List queued changes",
+	Run: func(cmd *cobra.Command, args []string) {
+		scheduleList()
+	},
+}
+
+func scheduleList() {
+	jobs, err := loadJobs()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 5, 2, ' ', 0)
+
+	for _, job := range jobs {
+		status := job.Status
+		if job.Error != "" {
+			status += " (" + job.Error + ")"
+		}
+
+		fmt.Fprint(w, job.ID, "\t", job.At.Format("2006-01-02 15:04:05"), "\t", status, "\t", job.URL, "\t", len(job.Changes), " changes\t", job.Command, "\n")
+	}
+
+	if len(jobs) == 0 {
+		fmt.Fprint(w, "(no queued changes)\n")
+	}
+
+	w.Flush()
+}
+
+func init() {
+	scheduleCmd.AddCommand(scheduleListCmd)
+}
@@ -0,0 +1,96 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// scheduleRunCmd represents the schedule run command. It is started by the
+// scheduler for each due job, so a failing job only ends its own process.
+var scheduleRunCmd = &cobra.Command{
+	Use:    "run [id]",
+	Short:  "Apply queued change [id] now",
+	Hidden: true,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("Missing argument(s)")
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		scheduleRun(args[0])
+	},
+}
+
+func scheduleRun(id string) {
+	job := findJob(id)
+	if job.Status != "pending" {
+		log.Fatal("Scheduled change ", job.ID, " is ", job.Status)
+	}
+
+	// The credentials come from the profile, which has to still point to
+	// the vTM the job was queued for.
+	if !isProfile(job.Profile) {
+		log.Fatal("Scheduled change ", job.ID, " uses unknown profile ", job.Profile, ", not applying")
+	}
+	conn := profileConnection(job.Profile)
+	if conn.URL != job.URL {
+		log.Fatal("Scheduled change ", job.ID, " was queued for ", job.URL, " but profile ", job.Profile, " now uses ", conn.URL, ", not applying")
+	}
+
+	// Policy and freeze windows of the profile apply.
+	viper.Set("profile", job.Profile)
+	force = job.Force
+	overrideFreeze = job.OverrideFreeze
+	assumeYes = true
+
+	fmt.Println("Running", job.ID+":", job.Command)
+	client := newClient(conn)
+
+	if err := verifyUnchanged(&client, job.Changes); err != nil {
+		log.Fatal(err)
+	}
+
+	for _, c := range job.Changes {
+		fmt.Print(c.Kind, " ", c.Name, ":\t", c.Field, " ", string(c.Before), " -> ", string(c.After), "\n")
+	}
+
+	entry := newJournalEntryFor(job.URL)
+	entry.Command = job.Command
+	if commitChanges(&client, job.Changes, entry) && job.Drain != nil {
+		job.Drain.apply(job.URL, job.Changes)
+	}
+
+	if err := job.remove(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func init() {
+	scheduleCmd.AddCommand(scheduleRunCmd)
+}
@@ -0,0 +1,91 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var schedulerInterval time.Duration
+
+// schedulerCmd represents the scheduler command
+var schedulerCmd = &cobra.Command{
+	Use:   "scheduler",
+	Short: "Execute queued changes when they are due",
+	Long: `Execute changes queued with --at TIME --queue when they are due. Runs until
+stopped. Each job is executed as "schedule run [id]" in a separate process
+with the same config file; jobs that fail are kept with status failed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		scheduler()
+	},
+}
+
+func scheduler() {
+	self, err := os.Executable()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println("Scheduler started, checking queue every", schedulerInterval)
+
+	for {
+		jobs, err := loadJobs()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		for _, job := range jobs {
+			if job.Status != "pending" || job.At.After(time.Now()) {
+				continue
+			}
+
+			args := []string{"schedule", "run", job.ID}
+			if cfgFile != "" {
+				args = append([]string{"--config", cfgFile}, args...)
+			}
+
+			cmd := exec.Command(self, args...)
+			cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+
+			if err := cmd.Run(); err != nil {
+				job.Status, job.Error = "failed", err.Error()
+				if err := job.save(); err != nil {
+					log.Fatal(err)
+				}
+				fmt.Println(job.ID+":", "failed:", err)
+			}
+		}
+
+		time.Sleep(schedulerInterval)
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(schedulerCmd)
+
+	schedulerCmd.Flags().DurationVar(&schedulerInterval, "interval", 30*time.Second, "How often to check the queue.")
+}
@@ -0,0 +1,255 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var metricsListen string
+
+// serveMetricsCmd represents the serve-metrics command
+var serveMetricsCmd = &cobra.Command{
+	Use:   "serve-metrics",
+	Short: "Serve vserver, pool, node and traffic IP statistics as Prometheus metrics",
+	Long: `Serve statistics in the Prometheus text format on /metrics. Each scrape
+reads the counters of vservers, pools and pool nodes from the traffic manager
+the API URL points to, and the traffic IP states of every cluster member.
+
+The connection settings, including --profile, are the same as for all other
+commands.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		serveMetrics()
+	},
+}
+
+// metricFamily is a metric with all its samples of one scrape.
+type metricFamily struct {
+	name, typ, help string
+	samples         []string
+}
+
+// metricSet collects the metrics of one scrape in the order they were added.
+type metricSet struct {
+	families map[string]*metricFamily
+	order    []string
+}
+
+func newMetricSet() *metricSet {
+	return &metricSet{families: make(map[string]*metricFamily)}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// add adds a sample. labels are name, value pairs.
+func (m *metricSet) add(name, typ, help string, value float64, labels ...string) {
+	f, ok := m.families[name]
+	if !ok {
+		f = &metricFamily{name: name, typ: typ, help: help}
+		m.families[name] = f
+		m.order = append(m.order, name)
+	}
+
+	var pairs []string
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, labels[i]+`="`+labelEscaper.Replace(labels[i+1])+`"`)
+	}
+
+	sample := name
+	if len(pairs) > 0 {
+		sample += "{" + strings.Join(pairs, ",") + "}"
+	}
+	f.samples = append(f.samples, sample+" "+strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+func (m *metricSet) write(w io.Writer) {
+	for _, name := range m.order {
+		f := m.families[name]
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.typ)
+		for _, s := range f.samples {
+			fmt.Fprintln(w, s)
+		}
+	}
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+// metricsExporter answers scrapes.
+type metricsExporter struct {
+	api *apiClient
+
+	mu     sync.Mutex
+	errors float64
+}
+
+// collect reads all statistics into m. Errors are returned after collecting
+// whatever could be read.
+func (e *metricsExporter) collect(m *metricSet) []error {
+	var errs []error
+
+	vservers, err := e.api.children("status/local_tm/statistics/virtual_servers")
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for _, name := range vservers {
+		s, err := e.api.vserverStatistics(name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		l := []string{"vserver", name}
+		m.add("vtm_vserver_current_connections", "gauge", "Current connections of the vserver.", float64(s.CurrentConn), l...)
+		m.add("vtm_vserver_connections_total", "counter", "Connections handled by the vserver.", float64(s.TotalConn), l...)
+		m.add("vtm_vserver_bytes_in_total", "counter", "Bytes received by the vserver from clients.", float64(s.BytesIn), l...)
+		m.add("vtm_vserver_bytes_out_total", "counter", "Bytes sent by the vserver to clients.", float64(s.BytesOut), l...)
+		m.add("vtm_vserver_connection_errors_total", "counter", "Client connection errors of the vserver.", float64(s.ConnectionErrors), l...)
+		m.add("vtm_vserver_connection_failures_total", "counter", "Client connection failures of the vserver.", float64(s.ConnectionFailures), l...)
+	}
+
+	pools, err := e.api.children("status/local_tm/statistics/pools")
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for _, pool := range pools {
+		s, err := e.api.poolStatistics(pool)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		l := []string{"pool", pool}
+		m.add("vtm_pool_active", "gauge", "1 if the pool is active.", boolValue(s.State == "active"), l...)
+		m.add("vtm_pool_nodes", "gauge", "Nodes of the pool.", float64(s.Nodes), l...)
+		m.add("vtm_pool_disabled_nodes", "gauge", "Disabled nodes of the pool.", float64(s.Disabled), l...)
+		m.add("vtm_pool_draining_nodes", "gauge", "Draining nodes of the pool.", float64(s.Draining), l...)
+		m.add("vtm_pool_connections_total", "counter", "Connections sent to the pool.", float64(s.TotalConn), l...)
+		m.add("vtm_pool_bytes_in_total", "counter", "Bytes received from the pool's nodes.", float64(s.BytesIn), l...)
+		m.add("vtm_pool_bytes_out_total", "counter", "Bytes sent to the pool's nodes.", float64(s.BytesOut), l...)
+		m.add("vtm_pool_queue_timeouts_total", "counter", "Requests that timed out waiting for a node of the pool.", float64(s.QueueTimeouts), l...)
+
+		nodes, err := e.api.poolNodes(pool)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, node := range nodes {
+			n, err := e.api.poolNodeStatistics(pool, node)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			l := []string{"pool", pool, "node", node}
+			m.add("vtm_pool_node_alive", "gauge", "1 if the node is alive.", boolValue(n.State == "alive"), l...)
+			m.add("vtm_pool_node_current_connections", "gauge", "Current connections to the node.", float64(n.CurrentConn), l...)
+			m.add("vtm_pool_node_connections_total", "counter", "Connections sent to the node.", float64(n.TotalConn), l...)
+			m.add("vtm_pool_node_errors_total", "counter", "Errors of the node.", float64(n.Errors), l...)
+			m.add("vtm_pool_node_failures_total", "counter", "Failures of the node.", float64(n.Failures), l...)
+			m.add("vtm_pool_node_response_mean_seconds", "gauge", "Mean response time of the node.", float64(n.ResponseMean)/1000, l...)
+			m.add("vtm_pool_node_response_max_seconds", "gauge", "Maximum response time of the node.", float64(n.ResponseMax)/1000, l...)
+		}
+	}
+
+	members, err := e.api.clusterMembers()
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for _, tm := range members {
+		states, err := e.api.trafficIPStates(tm)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		ips := make([]string, 0, len(states))
+		for ip := range states {
+			ips = append(ips, ip)
+		}
+		sort.Strings(ips)
+
+		for _, ip := range ips {
+			m.add("vtm_traffic_ip_raised", "gauge", "1 if the traffic manager has raised the traffic IP.", boolValue(states[ip] == "raised"), "tm", tm, "ip", ip)
+		}
+	}
+
+	return errs
+}
+
+func (e *metricsExporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	m := newMetricSet()
+	errs := e.collect(m)
+
+	e.mu.Lock()
+	e.errors += float64(len(errs))
+	scrapeErrors := e.errors
+	e.mu.Unlock()
+
+	for _, err := range errs {
+		log.Println("Scrape error:", err)
+	}
+
+	m.add("vtm_up", "gauge", "1 if the last scrape read all statistics.", boolValue(len(errs) == 0))
+	m.add("vtm_scrape_errors_total", "counter", "Errors while reading statistics.", scrapeErrors)
+	m.add("vtm_scrape_duration_seconds", "gauge", "Time the scrape took.", time.Since(start).Seconds())
+
+	var buf bytes.Buffer
+	m.write(&buf)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write(buf.Bytes())
+}
+
+func serveMetrics() {
+	exporter := &metricsExporter{api: initAPIClient()}
+
+	http.Handle("/metrics", exporter)
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `<html><body><a href="/metrics">Metrics</a></body></html>`)
+	})
+
+	log.Println("Serving metrics of", exporter.api.conn.URL, "on", metricsListen)
+	log.Fatal(http.ListenAndServe(metricsListen, nil))
+}
+
+func init() {
+	RootCmd.AddCommand(serveMetricsCmd)
+
+	serveMetricsCmd.Flags().StringVar(&metricsListen, "listen", ":9163", "Address to serve metrics on.")
+}
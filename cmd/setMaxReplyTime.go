@@ -28,7 +28,6 @@ import (
 
 	"github.com/gobwas/glob"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 // setMaxReplyTimeCmd represents the setMaxReplyTime command
@@ -61,13 +60,15 @@ func setMaxReplyTime(targetPool string, maxReplyTime int) {
 	poolGlob := glob.MustCompile(targetPool)
 	client := initClient()
 
-	fmt.Println("Getting pool list from", viper.Get("vtmAPIUrl"))
+	fmt.Println("Getting pool list from", currentConnection().URL)
 	poollist, resp, err := client.ListPools()
 	if err != nil {
 		log.Fatal(err)
 	}
 	fmt.Println("Response:", resp.Status)
 
+	var changes []change
+
 	for _, pool := range poollist {
 		if !poolGlob.Match(pool) {
 			continue
@@ -82,21 +83,18 @@ func setMaxReplyTime(targetPool string, maxReplyTime int) {
 
 		if currentMaxReplyTime != maxReplyTime {
 			fmt.Print(pool, ":\t", currentMaxReplyTime, "s -> ", maxReplyTime, "s\n")
-
-			if !dryRun {
-				*r.Connection.MaxReplyTime = maxReplyTime
-				_, err = client.Set(r)
-				if err != nil {
-					log.Fatal(err)
-				}
-			}
+			changes = append(changes, newChange("pool", pool, "connection.max_reply_time", r, maxReplyTime))
 		} else {
 			fmt.Print(pool, ":\t", currentMaxReplyTime, "s (no change)\n")
 		}
 
 	}
+
+	applyChanges(&client, changes)
 }
 
 func init() {
 	poolCmd.AddCommand(setMaxReplyTimeCmd)
+
+	addChangeFlags(setMaxReplyTimeCmd)
 }
@@ -0,0 +1,134 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/gobwas/glob"
+	"github.com/martinlindner/go-vtm"
+	"github.com/spf13/cobra"
+)
+
+// setPoolCmd represents the setPool command
+var setPoolCmd = &cobra.Command{
+	Use:   "setPool [vserver] [pool]",
+	Short: "Set default pool of [vserver] to [pool]",
+	Long: `Set the default pool of [vserver] to [pool]. The pool has to exist and have
+at least one active node that the traffic manager reports as alive.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 2 {
+			return errors.New("Missing argument(s)")
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		setPool(args[0], args[1])
+	},
+}
+
+func setPool(targetVserver, pool string) {
+	if dryRun {
+		fmt.Println(dryRunC)
+	}
+
+	vserverGlob := glob.MustCompile(targetVserver)
+	client := initClient()
+
+	checkPoolHealthy(&client, pool)
+
+	fmt.Println("Getting vserver list from", currentConnection().URL)
+	serverlist, resp, err := client.ListVirtualServers()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Response:", resp.Status)
+
+	var changes []change
+
+	for _, vserver := range serverlist {
+		if !vserverGlob.Match(vserver) {
+			continue
+		}
+
+		r, _, err := client.GetVirtualServer(vserver)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		currentPool := ""
+		if r.Basic.Pool != nil {
+			currentPool = *r.Basic.Pool
+		}
+
+		if currentPool != pool {
+			fmt.Print(vserver, ":\t", currentPool, " -> ", pool, "\n")
+			changes = append(changes, newChange("vserver", vserver, "basic.pool", r, pool))
+		} else {
+			fmt.Print(vserver, ":\t", currentPool, " (no change)\n")
+		}
+	}
+
+	applyChanges(&client, changes)
+}
+
+// checkPoolHealthy exits unless pool exists and has at least one active node
+// that is alive.
+func checkPoolHealthy(client *stingray.Client, pool string) {
+	r, _, err := client.GetPool(pool)
+	if err != nil {
+		log.Fatal("Pool ", pool, ": ", err)
+	}
+
+	api := initAPIClient()
+	alive, total := 0, 0
+
+	if r.Basic.NodesTable != nil {
+		for _, node := range *r.Basic.NodesTable {
+			if node.Node == nil || (node.State != nil && *node.State != "active") {
+				continue
+			}
+			total++
+
+			s, err := api.poolNodeStatistics(pool, *node.Node)
+			if err != nil {
+				log.Fatal("Pool ", pool, ": ", err)
+			}
+			if s.State == "alive" {
+				alive++
+			}
+		}
+	}
+
+	fmt.Print("pool ", pool, ":\t", alive, "/", total, " active nodes alive\n")
+	if alive == 0 {
+		log.Fatal("Pool ", pool, " has no healthy nodes, not switching")
+	}
+}
+
+func init() {
+	vserverCmd.AddCommand(setPoolCmd)
+
+	addChangeFlags(setPoolCmd)
+}
@@ -28,7 +28,6 @@ import (
 
 	"github.com/gobwas/glob"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 // setTimeoutCmd represents the setTimeout command
@@ -61,13 +60,15 @@ func setTimeout(targetVserver string, timeout int) {
 	vserverGlob := glob.MustCompile(targetVserver)
 	client := initClient()
 
-	fmt.Println("Getting vserver list from", viper.Get("vtmAPIUrl"))
+	fmt.Println("Getting vserver list from", currentConnection().URL)
 	serverlist, resp, err := client.ListVirtualServers()
 	if err != nil {
 		log.Fatal(err)
 	}
 	fmt.Println("Response:", resp.Status)
 
+	var changes []change
+
 	for _, vserver := range serverlist {
 		if !vserverGlob.Match(vserver) {
 			continue
@@ -82,20 +83,17 @@ func setTimeout(targetVserver string, timeout int) {
 
 		if currentTimeout != timeout {
 			fmt.Print(vserver, ":\t", currentTimeout, "s -> ", timeout, "s\n")
-
-			if !dryRun {
-				*r.Connection.Timeout = timeout
-				_, err = client.Set(r)
-				if err != nil {
-					log.Fatal(err)
-				}
-			}
+			changes = append(changes, newChange("vserver", vserver, "connection.timeout", r, timeout))
 		} else {
 			fmt.Print(vserver, ":\t", currentTimeout, "s (no change)\n")
 		}
 	}
+
+	applyChanges(&client, changes)
 }
 
 func init() {
 	vserverCmd.AddCommand(setTimeoutCmd)
+
+	addChangeFlags(setTimeoutCmd)
 }
@@ -0,0 +1,87 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+// snapshotCmd represents the snapshot command
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot [directory]",
+	Short: "Save vserver, pool and rule configuration to [directory]",
+	Long: `Save the vserver, pool and rule configuration of the cluster to [directory].
+
+The snapshot can later be compared against a live cluster with the diff command.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("Missing argument(s)")
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		snapshot(args[0])
+	},
+}
+
+func snapshot(dir string) {
+	source := apiSource{initAPIClient()}
+
+	for _, kind := range configKinds {
+		fmt.Println("Getting", kind.Name, "list from", source)
+		names, err := source.listObjects(kind)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		kindDir := filepath.Join(dir, kind.Path)
+		if err := os.MkdirAll(kindDir, 0755); err != nil {
+			log.Fatal(err)
+		}
+
+		for _, name := range names {
+			data, err := source.readObject(kind, name)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			err = ioutil.WriteFile(filepath.Join(kindDir, url.PathEscape(name)), data, 0644)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		fmt.Print(kind.Path, ":\t", len(names), " saved\n")
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(snapshotCmd)
+}
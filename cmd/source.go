@@ -0,0 +1,125 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// configKind is an object type that snapshot and diff know how to handle.
+type configKind struct {
+	Name string // name used in output, e.g. "vserver"
+	Path string // collection below config/active, e.g. "virtual_servers"
+}
+
+var configKinds = []configKind{
+	{Name: "vserver", Path: "virtual_servers"},
+	{Name: "pool", Path: "pools"},
+	{Name: "rule", Path: "rules"},
+}
+
+// configSource is something vTM configuration objects can be read from: a
+// live cluster or a snapshot directory.
+type configSource interface {
+	String() string
+	listObjects(kind configKind) ([]string, error)
+	readObject(kind configKind, name string) ([]byte, error)
+}
+
+// openSource resolves a source argument. It may be an http(s) URL, which is
+// used with the current credentials, a profile name or a snapshot directory.
+func openSource(arg string) (configSource, error) {
+	if strings.HasPrefix(arg, "http://") || strings.HasPrefix(arg, "https://") {
+		conn := currentConnection()
+		conn.URL = arg
+		return apiSource{newAPIClient(conn)}, nil
+	}
+
+	if isProfile(arg) {
+		return apiSource{newAPIClient(profileConnection(arg))}, nil
+	}
+
+	if fi, err := os.Stat(arg); err == nil && fi.IsDir() {
+		return snapshotSource(arg), nil
+	}
+
+	return nil, fmt.Errorf("%s is neither a URL, a profile nor a snapshot directory", arg)
+}
+
+// apiSource reads configuration from the REST API of a cluster.
+type apiSource struct {
+	*apiClient
+}
+
+func (s apiSource) String() string {
+	return s.conn.URL
+}
+
+func (s apiSource) listObjects(kind configKind) ([]string, error) {
+	return s.children("config/active/" + kind.Path)
+}
+
+func (s apiSource) readObject(kind configKind, name string) ([]byte, error) {
+	return s.get("config/active/" + kind.Path + "/" + url.PathEscape(name))
+}
+
+// snapshotSource reads configuration from a directory written by the snapshot
+// command. It mirrors the API layout: one file per object named
+// <dir>/<collection>/<object>, holding the document returned by the API.
+type snapshotSource string
+
+func (s snapshotSource) String() string {
+	return string(s)
+}
+
+func (s snapshotSource) listObjects(kind configKind) ([]string, error) {
+	files, err := ioutil.ReadDir(filepath.Join(string(s), kind.Path))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+
+		name, err := url.PathUnescape(f.Name())
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+func (s snapshotSource) readObject(kind configKind, name string) ([]byte, error) {
+	return ioutil.ReadFile(filepath.Join(string(s), kind.Path, url.PathEscape(name)))
+}
@@ -0,0 +1,172 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/martinlindner/go-vtm"
+	"github.com/spf13/cobra"
+)
+
+// sslCmd represents the ssl command
+var sslCmd = &cobra.Command{
+	Use:   "ssl",
+	Short: "SSL server certificate subcommands",
+}
+
+// sslCert is an SSL server certificate of the cluster.
+type sslCert struct {
+	Name     string    `json:"name"`
+	Subject  string    `json:"subject"`
+	SANs     []string  `json:"sans"`
+	Issuer   string    `json:"issuer"`
+	NotAfter time.Time `json:"not_after"`
+	Vservers []string  `json:"vservers"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// parseCertificate returns the first certificate in a PEM document.
+func parseCertificate(data string) (*x509.Certificate, error) {
+	rest := []byte(data)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil, errors.New("no certificate found")
+		}
+		if block.Type == "CERTIFICATE" {
+			return x509.ParseCertificate(block.Bytes)
+		}
+	}
+}
+
+func certName(name pkix.Name) string {
+	if name.CommonName != "" {
+		return name.CommonName
+	}
+
+	return name.String()
+}
+
+// certReferences returns the vservers using each certificate, either as
+// default certificate or for a host name (as "vserver (host)").
+func certReferences(client *stingray.Client) (map[string][]string, error) {
+	serverlist, _, err := client.ListVirtualServers()
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make(map[string][]string)
+	for _, vserver := range serverlist {
+		r, _, err := client.GetVirtualServer(vserver)
+		if err != nil {
+			return nil, err
+		}
+
+		if c := r.SSL.ServerCertDefault; c != nil && *c != "" {
+			refs[*c] = append(refs[*c], vserver)
+		}
+		if r.SSL.ServerCertHostMapping != nil {
+			for _, m := range *r.SSL.ServerCertHostMapping {
+				if m.Certificate != nil && m.Host != nil {
+					refs[*m.Certificate] = append(refs[*m.Certificate], vserver+" ("+*m.Host+")")
+				}
+			}
+		}
+	}
+
+	return refs, nil
+}
+
+// loadSSLCerts reads and parses all SSL server certificates.
+func loadSSLCerts(client *stingray.Client) ([]sslCert, error) {
+	progress("Getting SSL server certificates from", currentConnection().URL)
+	names, resp, err := client.ListSSLServerKeys()
+	if err != nil {
+		return nil, err
+	}
+	progress("Response:", resp.Status)
+
+	refs, err := certReferences(client)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	certs := make([]sslCert, 0, len(names))
+	for _, name := range names {
+		c := sslCert{Name: name, Vservers: refs[name]}
+
+		r, _, err := client.GetSSLServerKey(name)
+		if err != nil {
+			return nil, err
+		}
+
+		if r.Basic.Public == nil {
+			c.Error = "no certificate"
+		} else if cert, err := parseCertificate(*r.Basic.Public); err != nil {
+			c.Error = err.Error()
+		} else {
+			c.Subject = certName(cert.Subject)
+			c.Issuer = certName(cert.Issuer)
+			c.NotAfter = cert.NotAfter
+			c.SANs = append(c.SANs, cert.DNSNames...)
+			for _, ip := range cert.IPAddresses {
+				c.SANs = append(c.SANs, ip.String())
+			}
+			c.SANs = append(c.SANs, cert.EmailAddresses...)
+		}
+
+		certs = append(certs, c)
+	}
+
+	return certs, nil
+}
+
+func printCerts(out io.Writer, certs []sslCert) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSUBJECT\tSANS\tISSUER\tEXPIRES\tDAYS\tVSERVERS")
+	for _, c := range certs {
+		if c.Error != "" {
+			fmt.Fprintf(w, "%s\t(%s)\t\t\t\t\t%s\n", c.Name, c.Error, strings.Join(c.Vservers, ", "))
+			continue
+		}
+
+		days := int(time.Until(c.NotAfter).Hours() / 24)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%s\n", c.Name, c.Subject, strings.Join(c.SANs, ", "), c.Issuer,
+			c.NotAfter.Local().Format("2006-01-02"), days, strings.Join(c.Vservers, ", "))
+	}
+	w.Flush()
+}
+
+func init() {
+	RootCmd.AddCommand(sslCmd)
+}
@@ -0,0 +1,108 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var sslWithin string
+
+// sslExpiringCmd represents the ssl expiring command
+var sslExpiringCmd = &cobra.Command{
+	Use:   "expiring",
+	Short: "List SSL server certificates expiring within --within",
+	Long: `List SSL server certificates that expire within --within (e.g. 30d or 72h)
+or have already expired, and those that cannot be parsed. Exits with 1 if
+there are any.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		within, err := parseDays(sslWithin)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		expiring := false
+		watch(func(out io.Writer) (err error) {
+			expiring, err = sslExpiring(out, within)
+			return err
+		})
+
+		if expiring {
+			os.Exit(1)
+		}
+	},
+}
+
+// parseDays parses a duration that may also be given in days, e.g. 30d.
+func parseDays(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err == nil {
+			return time.Duration(days) * 24 * time.Hour, nil
+		}
+	}
+
+	return time.ParseDuration(s)
+}
+
+func sslExpiring(out io.Writer, within time.Duration) (bool, error) {
+	client := initClient()
+	deadline := time.Now().Add(within)
+
+	certs, err := loadSSLCerts(&client)
+	if err != nil {
+		return false, err
+	}
+
+	var expiring []sslCert
+	for _, c := range certs {
+		// A certificate that cannot be parsed may have expired as well.
+		if c.Error != "" || c.NotAfter.Before(deadline) {
+			expiring = append(expiring, c)
+		}
+	}
+
+	if jsonOutput() {
+		if expiring == nil {
+			expiring = []sslCert{}
+		}
+		fprintJSON(out, expiring)
+	} else {
+		printCerts(out, expiring)
+	}
+
+	return len(expiring) > 0, nil
+}
+
+func init() {
+	sslCmd.AddCommand(sslExpiringCmd)
+
+	sslExpiringCmd.Flags().StringVar(&sslWithin, "within", "30d", "Time window, e.g. 30d or 72h.")
+	sslExpiringCmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table or json).")
+	sslExpiringCmd.Flags().DurationVar(&watchInterval, "watch", 0, "Re-run at this interval, highlighting changed lines.")
+}
@@ -0,0 +1,196 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"time"
+
+	"github.com/martinlindner/go-vtm"
+	"github.com/spf13/cobra"
+)
+
+var (
+	sslImportCert  string
+	sslImportKey   string
+	sslImportChain string
+)
+
+// sslImportCmd represents the ssl import command
+var sslImportCmd = &cobra.Command{
+	Use:   "import [name]",
+	Short: "Import a certificate and key as SSL server certificate [name]",
+	Long: `Import a certificate and its private key as SSL server certificate [name].
+The key has to match the certificate, and each certificate of --chain has to
+have signed the one before it. Existing certificates are not replaced; import
+under a new name and switch vservers over with ssl rotate.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 || sslImportCert == "" || sslImportKey == "" {
+			return errors.New("Missing argument(s)")
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		sslImport(args[0])
+	},
+}
+
+// parseCertificates returns all certificates in a PEM document.
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return certs, nil
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+}
+
+// verifyCertificate checks that key belongs to the certificate, that each
+// certificate of the chain signed the one before it and that all of them are
+// valid now.
+func verifyCertificate(certPEM, keyPEM, chainPEM []byte) (*x509.Certificate, error) {
+	if _, err := tls.X509KeyPair(certPEM, keyPEM); err != nil {
+		return nil, err
+	}
+
+	certs, err := parseCertificates(certPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	chain, err := parseCertificates(chainPEM)
+	if err != nil {
+		return nil, err
+	}
+	if chainPEM != nil && len(chain) == 0 {
+		return nil, errors.New("--chain contains no certificates")
+	}
+	certs = append(certs, chain...)
+
+	now := time.Now()
+	for _, c := range certs {
+		if now.Before(c.NotBefore) {
+			return nil, fmt.Errorf("%s is not valid before %s", certName(c.Subject), c.NotBefore.Local().Format("2006-01-02 15:04:05"))
+		}
+		if now.After(c.NotAfter) {
+			return nil, fmt.Errorf("%s expired %s", certName(c.Subject), c.NotAfter.Local().Format("2006-01-02 15:04:05"))
+		}
+	}
+
+	for i := 1; i < len(certs); i++ {
+		if err := certs[i-1].CheckSignatureFrom(certs[i]); err != nil {
+			return nil, fmt.Errorf("%s is not signed by %s: %v", certName(certs[i-1].Subject), certName(certs[i].Subject), err)
+		}
+	}
+
+	return certs[0], nil
+}
+
+func sslImport(name string) {
+	if dryRun {
+		fmt.Println(dryRunC)
+	}
+
+	read := func(file string) []byte {
+		if file == "" {
+			return nil
+		}
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return data
+	}
+
+	certPEM, keyPEM, chainPEM := read(sslImportCert), read(sslImportKey), read(sslImportChain)
+
+	cert, err := verifyCertificate(certPEM, keyPEM, chainPEM)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	client := initClient()
+	enforceWrite()
+
+	fmt.Println("Getting SSL server certificate list from", currentConnection().URL)
+	names, resp, err := client.ListSSLServerKeys()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Response:", resp.Status)
+
+	for _, n := range names {
+		if n == name {
+			log.Fatal("SSL server certificate ", name, " already exists")
+		}
+	}
+
+	fmt.Print(name, ":\t", certName(cert.Subject), ", expires ", cert.NotAfter.Local().Format("2006-01-02"), "\n")
+	if dryRun {
+		return
+	}
+
+	public := string(certPEM)
+	if len(chainPEM) > 0 {
+		public += "\n" + string(chainPEM)
+	}
+	private := string(keyPEM)
+
+	r := stingray.NewSSLServerKey(name)
+	r.Basic.Public = &public
+	r.Basic.Private = &private
+
+	audit := openAudit()
+	defer audit.Close()
+
+	resp, err = client.Set(r)
+	audit.action("ssl import", "ssl "+name, err)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Response:", resp.Status)
+}
+
+func init() {
+	sslCmd.AddCommand(sslImportCmd)
+
+	sslImportCmd.Flags().StringVar(&sslImportCert, "cert", "", "Certificate file (PEM).")
+	sslImportCmd.Flags().StringVar(&sslImportKey, "key", "", "Private key file (PEM).")
+	sslImportCmd.Flags().StringVar(&sslImportChain, "chain", "", "Intermediate certificates file (PEM), in order from the issuer of the certificate upwards.")
+}
@@ -0,0 +1,61 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+// sslListCmd represents the ssl list command
+var sslListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List SSL server certificates with expiry and the vservers using them",
+	Run: func(cmd *cobra.Command, args []string) {
+		watch(func(out io.Writer) error {
+			return sslList(out)
+		})
+	},
+}
+
+func sslList(out io.Writer) error {
+	client := initClient()
+	certs, err := loadSSLCerts(&client)
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput() {
+		fprintJSON(out, certs)
+	} else {
+		printCerts(out, certs)
+	}
+
+	return nil
+}
+
+func init() {
+	sslCmd.AddCommand(sslListCmd)
+
+	sslListCmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table or json).")
+	sslListCmd.Flags().DurationVar(&watchInterval, "watch", 0, "Re-run at this interval, highlighting changed lines.")
+}
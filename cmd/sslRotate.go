@@ -0,0 +1,129 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/martinlindner/go-vtm"
+	"github.com/spf13/cobra"
+)
+
+// sslRotateCmd represents the ssl rotate command
+var sslRotateCmd = &cobra.Command{
+	Use:   "rotate [old] [new]",
+	Short: "Switch every vserver using certificate [old] to certificate [new]",
+	Long: `Switch every vserver using SSL server certificate [old], as default
+certificate or for a host name (SNI), to certificate [new].`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 2 {
+			return errors.New("Missing argument(s)")
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		sslRotate(args[0], args[1])
+	},
+}
+
+func sslRotate(oldCert, newCert string) {
+	if dryRun {
+		fmt.Println(dryRunC)
+	}
+
+	client := initClient()
+
+	if _, _, err := client.GetSSLServerKey(newCert); err != nil {
+		log.Fatal("SSL server certificate ", newCert, ": ", err)
+	}
+
+	fmt.Println("Getting vserver list from", currentConnection().URL)
+	serverlist, resp, err := client.ListVirtualServers()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Response:", resp.Status)
+
+	var changes []change
+
+	for _, vserver := range serverlist {
+		r, _, err := client.GetVirtualServer(vserver)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		changes = append(changes, rotateChanges(vserver, r, oldCert, newCert)...)
+	}
+
+	if len(changes) == 0 {
+		fmt.Println("No vserver uses", oldCert)
+	}
+
+	applyChanges(&client, changes)
+}
+
+// rotateChanges returns the changes switching vserver r from oldCert to
+// newCert. Both changes share r, so they are applied and undone together.
+func rotateChanges(vserver string, r *stingray.VirtualServer, oldCert, newCert string) []change {
+	var changes []change
+
+	if c := r.SSL.ServerCertDefault; c != nil && *c == oldCert {
+		fmt.Print(vserver, ":\tdefault ", oldCert, " -> ", newCert, "\n")
+		changes = append(changes, newChange("vserver", vserver, "ssl.server_cert_default", r, newCert))
+	}
+
+	if r.SSL.ServerCertHostMapping == nil {
+		return changes
+	}
+
+	// Copy the mappings, r still has to hold the current state.
+	mappings := append(stingray.ServerCertHostMappingTable(nil), *r.SSL.ServerCertHostMapping...)
+	hasUpdates := false
+
+	for index, m := range mappings {
+		if m.Certificate != nil && *m.Certificate == oldCert {
+			cert := newCert
+			mappings[index].Certificate = &cert
+			hasUpdates = true
+
+			host := ""
+			if m.Host != nil {
+				host = *m.Host
+			}
+			fmt.Print(vserver, ":\t", host, " ", oldCert, " -> ", newCert, "\n")
+		}
+	}
+
+	if hasUpdates {
+		changes = append(changes, newChange("vserver", vserver, "ssl.server_cert_host_mapping", r, mappings))
+	}
+
+	return changes
+}
+
+func init() {
+	sslCmd.AddCommand(sslRotateCmd)
+
+	addChangeFlags(sslRotateCmd)
+}
@@ -0,0 +1,76 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/martinlindner/go-vtm"
+)
+
+func TestRotateDefaultAndHostCertificate(t *testing.T) {
+	r := sslVirtualServer("old", "old")
+
+	changes := rotateChanges("www", r, "old", "new")
+	if len(changes) != 2 {
+		t.Fatalf("got %d changes, want 2", len(changes))
+	}
+
+	// Apply the rotation to a fresh copy of the vserver.
+	rotated := sslVirtualServer("old", "old")
+	err := shareObjects(changes, func(c *change) (stingray.Resourcer, error) {
+		return rotated, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conflicts, err := checkInOrder(nil, changes, nil); err != nil || len(conflicts) > 0 {
+		t.Fatal("rotate failed:", conflicts, err)
+	}
+	if *rotated.SSL.ServerCertDefault != "new" || *(*rotated.SSL.ServerCertHostMapping)[0].Certificate != "new" {
+		t.Fatal("vserver is not fully rotated")
+	}
+
+	// Undo them against the rotated state, as undo and revert do.
+	var reverts []change
+	for i := len(changes) - 1; i >= 0; i-- {
+		c := changes[i].reverse()
+		c.object = nil
+		reverts = append(reverts, c)
+	}
+	err = shareObjects(reverts, func(c *change) (stingray.Resourcer, error) {
+		return sslVirtualServer("new", "new"), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conflicts, err := checkInOrder(nil, reverts, nil); err != nil || len(conflicts) > 0 {
+		t.Fatal("undo failed:", conflicts, err)
+	}
+
+	restored := reverts[len(reverts)-1].object.(*stingray.VirtualServer)
+	if *restored.SSL.ServerCertDefault != "old" {
+		t.Error("default certificate was not restored")
+	}
+	if *(*restored.SSL.ServerCertHostMapping)[0].Certificate != "old" {
+		t.Error("host certificate was not restored")
+	}
+}
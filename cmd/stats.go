@@ -0,0 +1,159 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/gobwas/glob"
+)
+
+// statsInterval is the --interval of the stats commands. If set, rates are
+// computed from two samples taken that far apart.
+var statsInterval time.Duration
+
+// vserverStatistics are the counters of a virtual server on one traffic
+// manager.
+type vserverStatistics struct {
+	CurrentConn        int64 `json:"current_conn"`
+	TotalConn          int64 `json:"total_conn"`
+	BytesIn            int64 `json:"bytes_in"`
+	BytesOut           int64 `json:"bytes_out"`
+	ConnectionErrors   int64 `json:"connection_errors"`
+	ConnectionFailures int64 `json:"connection_failures"`
+}
+
+// poolStatistics are the counters of a pool on one traffic manager.
+type poolStatistics struct {
+	State         string `json:"state"`
+	Nodes         int64  `json:"nodes"`
+	Disabled      int64  `json:"disabled"`
+	Draining      int64  `json:"draining"`
+	TotalConn     int64  `json:"total_conn"`
+	BytesIn       int64  `json:"bytes_in"`
+	BytesOut      int64  `json:"bytes_out"`
+	QueueTimeouts int64  `json:"queue_timeouts"`
+}
+
+// poolNodeStatistics are the counters of a node in a particular pool.
+type poolNodeStatistics struct {
+	State        string `json:"state"`
+	CurrentConn  int64  `json:"current_conn"`
+	TotalConn    int64  `json:"total_conn"`
+	Errors       int64  `json:"errors"`
+	Failures     int64  `json:"failures"`
+	ResponseMean int64  `json:"response_mean"`
+	ResponseMax  int64  `json:"response_max"`
+}
+
+// statistics reads the statistics document of an object from the traffic
+// manager the client is connected to, e.g. ("virtual_servers", "web").
+func (a *apiClient) statistics(collection, name string, v interface{}) error {
+	doc := struct {
+		Statistics interface{} `json:"statistics"`
+	}{v}
+
+	return a.getJSON("status/local_tm/statistics/"+collection+"/"+url.PathEscape(name), &doc)
+}
+
+func (a *apiClient) vserverStatistics(name string) (vserverStatistics, error) {
+	var s vserverStatistics
+	err := a.statistics("virtual_servers", name, &s)
+
+	return s, err
+}
+
+func (a *apiClient) poolStatistics(name string) (poolStatistics, error) {
+	var s poolStatistics
+	err := a.statistics("pools", name, &s)
+
+	return s, err
+}
+
+func (a *apiClient) poolNodeStatistics(pool, node string) (poolNodeStatistics, error) {
+	var s poolNodeStatistics
+	err := a.statistics("nodes/per_pool_node", pool+"-"+node, &s)
+
+	return s, err
+}
+
+// configNode is a node of a pool's nodes table.
+type configNode struct {
+	Node  string `json:"node"`
+	State string `json:"state"`
+}
+
+// poolNodesTable returns the nodes table of pool.
+func (a *apiClient) poolNodesTable(pool string) ([]configNode, error) {
+	var doc struct {
+		Properties struct {
+			Basic struct {
+				NodesTable []configNode `json:"nodes_table"`
+			} `json:"basic"`
+		} `json:"properties"`
+	}
+	err := a.getJSON("config/active/pools/"+url.PathEscape(pool), &doc)
+
+	return doc.Properties.Basic.NodesTable, err
+}
+
+// poolNodes returns the nodes configured in pool.
+func (a *apiClient) poolNodes(pool string) ([]string, error) {
+	table, err := a.poolNodesTable(pool)
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes []string
+	for _, n := range table {
+		nodes = append(nodes, n.Node)
+	}
+
+	return nodes, nil
+}
+
+// statisticsNames returns the names of the objects in collection that have
+// statistics and match g.
+func (a *apiClient) statisticsNames(collection string, g glob.Glob) ([]string, error) {
+	names, err := a.children("status/local_tm/statistics/" + collection)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []string
+	for _, name := range names {
+		if g.Match(name) {
+			matched = append(matched, name)
+		}
+	}
+
+	return matched, nil
+}
+
+// perSecond returns the rate of a counter between two samples.
+func perSecond(before, after int64, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+
+	return float64(after-before) / d.Seconds()
+}
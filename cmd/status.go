@@ -0,0 +1,287 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// statusCmd represents the status command
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the health of the cluster",
+	Long: `Show each traffic manager of the cluster with its state and the traffic IP
+addresses it hosts, failed pool nodes, and configuration errors and warnings.
+
+Exits with 1 if a traffic manager is unreachable or reports errors, or if a
+pool node has failed. The messages of a traffic manager at warn level are
+listed as warnings and do not change the exit code.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		healthy := true
+		watch(func(out io.Writer) (err error) {
+			healthy, err = status(out)
+			return err
+		})
+
+		if !healthy {
+			os.Exit(1)
+		}
+	},
+}
+
+// tmState is the state document of a traffic manager.
+type tmState struct {
+	ErrorLevel  string   `json:"error_level"`
+	Errors      []string `json:"errors"`
+	TipErrors   []string `json:"tip_errors"`
+	FailedNodes []struct {
+		Node  string   `json:"node"`
+		Pools []string `json:"pools"`
+	} `json:"failed_nodes"`
+}
+
+type tmStatus struct {
+	Name       string   `json:"name"`
+	State      string   `json:"state"`
+	TrafficIPs []string `json:"traffic_ips"`
+}
+
+type failedNode struct {
+	Node  string   `json:"node"`
+	Pools []string `json:"pools"`
+}
+
+type clusterStatus struct {
+	Members     []tmStatus   `json:"members"`
+	FailedNodes []failedNode `json:"failed_nodes"`
+	Errors      []string     `json:"errors"`
+	Warnings    []string     `json:"warnings"`
+	Healthy     bool         `json:"healthy"`
+}
+
+// tmState reads the state of the traffic manager tm.
+func (a *apiClient) tmState(tm string) (tmState, error) {
+	var doc struct {
+		State tmState `json:"state"`
+	}
+	err := a.getJSON("status/"+url.PathEscape(tm)+"/state", &doc)
+
+	return doc.State, err
+}
+
+// trafficIPStates returns the state of each traffic IP address on the
+// traffic manager tm, e.g. "raised" or "lowered".
+func (a *apiClient) trafficIPStates(tm string) (map[string]string, error) {
+	path := "status/" + url.PathEscape(tm) + "/statistics/traffic_ips/traffic_ip_inet46"
+	ips, err := a.children(path)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make(map[string]string)
+	for _, ip := range ips {
+		var doc struct {
+			Statistics struct {
+				State string `json:"state"`
+			} `json:"statistics"`
+		}
+		if err := a.getJSON(path+"/"+url.PathEscape(ip), &doc); err != nil {
+			return nil, err
+		}
+		states[ip] = doc.Statistics.State
+	}
+
+	return states, nil
+}
+
+// trafficIPs returns the traffic IP addresses raised on the traffic manager
+// tm.
+func (a *apiClient) trafficIPs(tm string) ([]string, error) {
+	states, err := a.trafficIPStates(tm)
+	if err != nil {
+		return nil, err
+	}
+
+	var raised []string
+	for ip, state := range states {
+		if state == "raised" {
+			raised = append(raised, ip)
+		}
+	}
+	sort.Strings(raised)
+
+	return raised, nil
+}
+
+// clusterMembers returns the names of the traffic managers in the cluster.
+func (a *apiClient) clusterMembers() ([]string, error) {
+	names, err := a.children("status")
+	if err != nil {
+		return nil, err
+	}
+
+	var members []string
+	for _, name := range names {
+		if name != "local_tm" {
+			members = append(members, name)
+		}
+	}
+	sort.Strings(members)
+
+	return members, nil
+}
+
+// clusterHealth collects the state of every traffic manager in the cluster.
+func clusterHealth(api *apiClient) (clusterStatus, error) {
+	members, err := api.clusterMembers()
+	if err != nil {
+		return clusterStatus{}, err
+	}
+
+	s := clusterStatus{Healthy: true}
+	failed := make(map[string]map[string]bool)
+
+	for _, tm := range members {
+		m := tmStatus{Name: tm}
+
+		state, err := api.tmState(tm)
+		if err != nil {
+			m.State = "unreachable"
+			s.Errors = append(s.Errors, tm+": "+err.Error())
+			s.Healthy = false
+			s.Members = append(s.Members, m)
+			continue
+		}
+
+		// The messages of a traffic manager at warn level are warnings.
+		m.State = state.ErrorLevel
+		messages := &s.Warnings
+		if m.State != "ok" && m.State != "warn" {
+			s.Healthy = false
+			messages = &s.Errors
+		}
+
+		for _, e := range append(state.Errors, state.TipErrors...) {
+			*messages = append(*messages, tm+": "+e)
+		}
+
+		for _, n := range state.FailedNodes {
+			if failed[n.Node] == nil {
+				failed[n.Node] = make(map[string]bool)
+			}
+			for _, pool := range n.Pools {
+				failed[n.Node][pool] = true
+			}
+		}
+
+		if m.TrafficIPs, err = api.trafficIPs(tm); err != nil {
+			s.Healthy = false
+			s.Errors = append(s.Errors, tm+": "+err.Error())
+		}
+
+		s.Members = append(s.Members, m)
+	}
+
+	var nodes []string
+	for node := range failed {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	for _, node := range nodes {
+		s.FailedNodes = append(s.FailedNodes, failedNode{node, mergeNames(failed[node])})
+		s.Healthy = false
+	}
+
+	return s, nil
+}
+
+func status(out io.Writer) (bool, error) {
+	api := initAPIClient()
+
+	progress("Getting cluster status from", api.conn.URL)
+	s, err := clusterHealth(api)
+	if err != nil {
+		return false, err
+	}
+
+	if jsonOutput() {
+		fprintJSON(out, s)
+	} else {
+		printStatus(out, s)
+	}
+
+	return s.Healthy, nil
+}
+
+func printStatus(out io.Writer, s clusterStatus) {
+	w := new(tabwriter.Writer)
+	w.Init(out, 0, 5, 2, ' ', 0)
+
+	for _, m := range s.Members {
+		fmt.Fprint(w, m.Name, ":\t", m.State, "\t", strings.Join(m.TrafficIPs, ", "), "\n")
+	}
+
+	if len(s.FailedNodes) > 0 {
+		fmt.Fprint(w, "\nfailed nodes:\n")
+		for _, n := range s.FailedNodes {
+			fmt.Fprint(w, "\t- ", n.Node, ":\t", strings.Join(n.Pools, ", "), "\n")
+		}
+	}
+
+	if len(s.Errors) > 0 {
+		fmt.Fprint(w, "\nerrors:\n")
+		for _, e := range s.Errors {
+			fmt.Fprint(w, "\t- ", e, "\n")
+		}
+	}
+
+	if len(s.Warnings) > 0 {
+		fmt.Fprint(w, "\nwarnings:\n")
+		for _, e := range s.Warnings {
+			fmt.Fprint(w, "\t- ", e, "\n")
+		}
+	}
+
+	w.Flush()
+
+	fmt.Fprintln(out)
+	if s.Healthy {
+		fmt.Fprintln(out, "Cluster is", healthyC)
+	} else {
+		fmt.Fprintln(out, "Cluster is", unhealthyC)
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(statusCmd)
+
+	statusCmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table or json).")
+	statusCmd.Flags().DurationVar(&watchInterval, "watch", 0, "Re-run at this interval, highlighting changed lines.")
+}
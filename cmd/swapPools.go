@@ -0,0 +1,109 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/gobwas/glob"
+	"github.com/spf13/cobra"
+)
+
+// swapPoolsCmd represents the swapPools command
+var swapPoolsCmd = &cobra.Command{
+	Use:   "swapPools [vserver] [pool] [pool]",
+	Short: "Switch [vserver] between two pools",
+	Long: `Switch every [vserver] using one of the two pools to the other one, e.g.
+from app-blue to app-green and back. Both pools have to exist and have at
+least one active node that the traffic manager reports as alive.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 3 {
+			return errors.New("Missing argument(s)")
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		swapPools(args[0], args[1], args[2])
+	},
+}
+
+func swapPools(targetVserver, poolA, poolB string) {
+	if dryRun {
+		fmt.Println(dryRunC)
+	}
+
+	vserverGlob := glob.MustCompile(targetVserver)
+	client := initClient()
+
+	fmt.Println("Getting vserver list from", currentConnection().URL)
+	serverlist, resp, err := client.ListVirtualServers()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Response:", resp.Status)
+
+	swap := map[string]string{poolA: poolB, poolB: poolA}
+	var changes []change
+	targets := make(map[string]bool)
+
+	for _, vserver := range serverlist {
+		if !vserverGlob.Match(vserver) {
+			continue
+		}
+
+		r, _, err := client.GetVirtualServer(vserver)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		currentPool := ""
+		if r.Basic.Pool != nil {
+			currentPool = *r.Basic.Pool
+		}
+
+		newPool, ok := swap[currentPool]
+		if !ok {
+			fmt.Print(vserver, ":\t", currentPool, " (not ", poolA, " or ", poolB, ", no change)\n")
+			continue
+		}
+
+		fmt.Print(vserver, ":\t", currentPool, " -> ", newPool, "\n")
+		changes = append(changes, newChange("vserver", vserver, "basic.pool", r, newPool))
+		targets[newPool] = true
+	}
+
+	for _, pool := range []string{poolA, poolB} {
+		if targets[pool] {
+			checkPoolHealthy(&client, pool)
+		}
+	}
+
+	applyChanges(&client, changes)
+}
+
+func init() {
+	vserverCmd.AddCommand(swapPoolsCmd)
+
+	addChangeFlags(swapPoolsCmd)
+}
@@ -0,0 +1,171 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/martinlindner/go-vtm"
+)
+
+// timebox is the --for duration after which changes are reverted.
+var timebox time.Duration
+
+// revertFile is kept while a time-boxed change is active, so the revert can
+// be resumed with the revert command if go-vtm-cli gets killed.
+type revertFile struct {
+	Until time.Time     `json:"until"`
+	Entry *journalEntry `json:"entry"`
+}
+
+func (f *revertFile) path() (string, error) {
+	dir, err := stateDir("revert")
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, f.Entry.ID+".json"), nil
+}
+
+func (f *revertFile) save() (string, error) {
+	path, err := f.path()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return path, ioutil.WriteFile(path, data, 0600)
+}
+
+func (f *revertFile) remove() {
+	if path, err := f.path(); err == nil {
+		os.Remove(path)
+	}
+}
+
+// loadRevertFiles returns the pending reverts, oldest first.
+func loadRevertFiles() ([]*revertFile, error) {
+	dir, err := stateDir("revert")
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var reverts []*revertFile
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		f := new(revertFile)
+		if err := json.Unmarshal(data, f); err != nil || f.Entry == nil {
+			return nil, fmt.Errorf("%s: invalid revert file", file)
+		}
+		reverts = append(reverts, f)
+	}
+
+	sort.Slice(reverts, func(i, j int) bool { return reverts[i].Entry.ID < reverts[j].Entry.ID })
+
+	return reverts, nil
+}
+
+// timeboxChanges keeps the changes of entry for the --for duration and then
+// reverts them, or earlier on Ctrl-C.
+func timeboxChanges(client *stingray.Client, entry *journalEntry, audit *auditLog) {
+	f := &revertFile{Until: time.Now().Add(timebox), Entry: entry}
+
+	path, err := f.save()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Warning: could not write revert file:", err)
+	} else {
+		fmt.Println("If interrupted, resume the revert with: go-vtm-cli revert", entry.ID, "("+path+")")
+	}
+
+	if waitUntil(f.Until, "Reverting", "revert now") {
+		fmt.Println("Interrupted, reverting now")
+	}
+	if !revertEntry(client, entry, audit) {
+		log.Fatal("Not every change was reverted, retry with: go-vtm-cli revert ", entry.ID)
+	}
+	f.remove()
+}
+
+// waitUntil shows a countdown until t, e.g. "Reverting in 29m59s, press
+// Ctrl-C to revert now". It returns true if interrupted with Ctrl-C or
+// SIGTERM.
+func waitUntil(t time.Time, action, interrupt string) bool {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sig)
+
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+
+	tty := isTerminal(os.Stdout)
+	if !tty {
+		fmt.Println(action, "at", t.Format("2006-01-02 15:04:05"))
+	}
+
+	for {
+		left := time.Until(t)
+		if left <= 0 {
+			break
+		}
+
+		if tty {
+			fmt.Printf("\r%s in %s, press Ctrl-C to %s ", action, left.Truncate(time.Second), interrupt)
+		}
+
+		select {
+		case <-sig:
+			fmt.Println()
+			return true
+		case <-tick.C:
+		}
+	}
+
+	if tty {
+		fmt.Println()
+	}
+
+	return false
+}
@@ -0,0 +1,155 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/gobwas/glob"
+	"github.com/martinlindner/go-vtm"
+	"github.com/spf13/cobra"
+)
+
+// tipgroupCmd represents the tipgroup command
+var tipgroupCmd = &cobra.Command{
+	Use:   "tipgroup",
+	Short: "traffic IP group subcommands",
+}
+
+func stringsOf(p *[]string) []string {
+	if p == nil {
+		return nil
+	}
+
+	return *p
+}
+
+func containsString(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setTipgroupEnabled enables or disables the traffic IP groups matching
+// targetGroup.
+func setTipgroupEnabled(targetGroup string, enabled bool) {
+	if dryRun {
+		fmt.Println(dryRunC)
+	}
+
+	groupGlob := glob.MustCompile(targetGroup)
+	client := initClient()
+
+	fmt.Println("Getting traffic IP group list from", currentConnection().URL)
+	grouplist, resp, err := client.ListTrafficIPGroups()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Response:", resp.Status)
+
+	state := map[bool]string{true: enabledC, false: disabledC}
+	var changes []change
+
+	for _, group := range grouplist {
+		if !groupGlob.Match(group) {
+			continue
+		}
+
+		r, _, err := client.GetTrafficIPGroup(group)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		current := r.Basic.Enabled != nil && *r.Basic.Enabled
+		if current != enabled {
+			fmt.Print(group, ":\t[", state[current], "] -> [", state[enabled], "]\n")
+			changes = append(changes, newChange("tipgroup", group, "basic.enabled", r, enabled))
+		} else {
+			fmt.Print(group, ":\t[", state[current], "] (no change)\n")
+		}
+	}
+
+	applyChanges(&client, changes)
+}
+
+// passiveChanges returns the changes that make tm a passive (or active)
+// member of each traffic IP group selected by match. Groups tm is not a
+// member of are skipped.
+func passiveChanges(client *stingray.Client, match func(group string) bool, tm string, passive bool) []change {
+	fmt.Println("Getting traffic IP group list from", currentConnection().URL)
+	grouplist, resp, err := client.ListTrafficIPGroups()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Response:", resp.Status)
+
+	role := map[bool]string{true: "passive", false: "active"}
+	var changes []change
+
+	for _, group := range grouplist {
+		if !match(group) {
+			continue
+		}
+
+		r, _, err := client.GetTrafficIPGroup(group)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if !containsString(stringsOf(r.Basic.Machines), tm) {
+			continue
+		}
+
+		slaves := stringsOf(r.Basic.Slaves)
+		current := containsString(slaves, tm)
+		if current == passive {
+			fmt.Print(group, ":\t", tm, " [", role[current], "] (no change)\n")
+			continue
+		}
+
+		var updated []string
+		for _, s := range slaves {
+			if s != tm {
+				updated = append(updated, s)
+			}
+		}
+		if passive {
+			updated = append(updated, tm)
+		}
+		if updated == nil {
+			updated = []string{}
+		}
+
+		fmt.Print(group, ":\t", tm, " [", role[current], "] -> [", role[passive], "]\n")
+		changes = append(changes, newChange("tipgroup", group, "basic.slaves", r, updated))
+	}
+
+	return changes
+}
+
+func init() {
+	RootCmd.AddCommand(tipgroupCmd)
+}
@@ -0,0 +1,170 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gobwas/glob"
+	"github.com/spf13/cobra"
+)
+
+// tipgroupListCmd represents the tipgroup list command
+var tipgroupListCmd = &cobra.Command{
+	Use:   "list [tipgroup]",
+	Short: "List traffic IP groups matching [tipgroup] (default all) and where their IPs are hosted",
+	Run: func(cmd *cobra.Command, args []string) {
+		target := "*"
+		if len(args) > 0 {
+			target = args[0]
+		}
+
+		watch(func(out io.Writer) error {
+			return tipgroupList(out, target)
+		})
+	},
+}
+
+type tipgroupIP struct {
+	IP       string   `json:"ip"`
+	HostedBy []string `json:"hosted_by"`
+}
+
+type tipgroupInfo struct {
+	Name     string       `json:"name"`
+	Enabled  bool         `json:"enabled"`
+	Mode     string       `json:"mode"`
+	Machines []string     `json:"machines"`
+	Passive  []string     `json:"passive"`
+	IPs      []tipgroupIP `json:"ips"`
+}
+
+// trafficIPHosts returns the traffic managers that have raised each traffic
+// IP address.
+func trafficIPHosts(api *apiClient) (map[string][]string, error) {
+	members, err := api.clusterMembers()
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := make(map[string][]string)
+	for _, tm := range members {
+		ips, err := api.trafficIPs(tm)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Warning:", tm+":", err)
+			continue
+		}
+		for _, ip := range ips {
+			hosts[ip] = append(hosts[ip], tm)
+		}
+	}
+
+	return hosts, nil
+}
+
+func tipgroupList(out io.Writer, targetGroup string) error {
+	groupGlob := glob.MustCompile(targetGroup)
+	client := initClient()
+
+	progress("Getting traffic IP group list from", currentConnection().URL)
+	grouplist, resp, err := client.ListTrafficIPGroups()
+	if err != nil {
+		return err
+	}
+	progress("Response:", resp.Status)
+
+	hosts, err := trafficIPHosts(initAPIClient())
+	if err != nil {
+		return err
+	}
+
+	var groups []tipgroupInfo
+	for _, group := range grouplist {
+		if !groupGlob.Match(group) {
+			continue
+		}
+
+		r, _, err := client.GetTrafficIPGroup(group)
+		if err != nil {
+			return err
+		}
+
+		g := tipgroupInfo{
+			Name:     group,
+			Enabled:  r.Basic.Enabled != nil && *r.Basic.Enabled,
+			Machines: stringsOf(r.Basic.Machines),
+			Passive:  stringsOf(r.Basic.Slaves),
+		}
+		if r.Basic.Mode != nil {
+			g.Mode = *r.Basic.Mode
+		}
+		for _, ip := range stringsOf(r.Basic.IPAddresses) {
+			g.IPs = append(g.IPs, tipgroupIP{ip, hosts[ip]})
+		}
+
+		groups = append(groups, g)
+	}
+
+	if jsonOutput() {
+		fprintJSON(out, groups)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIPGROUP\tSTATE\tMODE\tMACHINES\tIP\tHOSTED BY")
+	for _, g := range groups {
+		state := disabledC
+		if g.Enabled {
+			state = enabledC
+		}
+
+		var machines []string
+		for _, m := range g.Machines {
+			if containsString(g.Passive, m) {
+				m += " (passive)"
+			}
+			machines = append(machines, m)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s", g.Name, state, g.Mode, strings.Join(machines, ", "))
+		if len(g.IPs) == 0 {
+			fmt.Fprintln(w, "\t\t")
+		}
+		for i, ip := range g.IPs {
+			if i > 0 {
+				fmt.Fprint(w, "\t\t\t")
+			}
+			fmt.Fprintf(w, "\t%s\t%s\n", ip.IP, strings.Join(ip.HostedBy, ", "))
+		}
+	}
+	return w.Flush()
+}
+
+func init() {
+	tipgroupCmd.AddCommand(tipgroupListCmd)
+
+	tipgroupListCmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table or json).")
+	tipgroupListCmd.Flags().DurationVar(&watchInterval, "watch", 0, "Re-run at this interval, highlighting changed lines.")
+}
@@ -0,0 +1,57 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gobwas/glob"
+	"github.com/spf13/cobra"
+)
+
+// tipgroupSetActiveCmd represents the tipgroup setActive command
+var tipgroupSetActiveCmd = &cobra.Command{
+	Use:   "setActive [tipgroup] [tm]",
+	Short: "Make [tm] an active member of traffic IP group(s) [tipgroup] again",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 2 {
+			return errors.New("Missing argument(s)")
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if dryRun {
+			fmt.Println(dryRunC)
+		}
+
+		client := initClient()
+		changes := passiveChanges(&client, glob.MustCompile(args[0]).Match, args[1], false)
+		applyChanges(&client, changes)
+	},
+}
+
+func init() {
+	tipgroupCmd.AddCommand(tipgroupSetActiveCmd)
+
+	addChangeFlags(tipgroupSetActiveCmd)
+}
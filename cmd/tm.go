@@ -0,0 +1,136 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// tmCmd represents the tm command
+var tmCmd = &cobra.Command{
+	Use:   "tm",
+	Short: "traffic manager subcommands",
+}
+
+// drainRecord remembers the traffic IP groups a traffic manager was made
+// passive in by tm drain, so tm undrain only restores those.
+type drainRecord struct {
+	TM     string    `json:"tm"`
+	URL    string    `json:"url"`
+	Time   time.Time `json:"time"`
+	Groups []string  `json:"groups"`
+}
+
+func drainRecordPath(tm string) (string, error) {
+	dir, err := stateDir("drain")
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, url.PathEscape(tm)+".json"), nil
+}
+
+// loadDrainRecord returns the drain record of tm, or nil if there is none.
+func loadDrainRecord(tm string) (*drainRecord, error) {
+	path, err := drainRecordPath(tm)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var r drainRecord
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+func (r *drainRecord) save() error {
+	path, err := drainRecordPath(r.TM)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+func (r *drainRecord) remove() {
+	if path, err := drainRecordPath(r.TM); err == nil {
+		os.Remove(path)
+	}
+}
+
+// drainUpdate is the change to the drain record of a traffic manager that
+// goes with the changes of tm drain or tm undrain. It is queued with the
+// changes, so the scheduler updates the record once it applied them.
+type drainUpdate struct {
+	TM      string `json:"tm"`
+	Undrain bool   `json:"undrain,omitempty"`
+}
+
+// queuedDrain is the drain record update of the running command, stored
+// with its changes by --queue.
+var queuedDrain *drainUpdate
+
+// apply updates the drain record of d.TM once changes are in effect on the
+// vTM at vtmURL.
+func (d *drainUpdate) apply(vtmURL string, changes []change) {
+	if !d.Undrain {
+		recordDrain(d.TM, vtmURL, changes)
+		return
+	}
+
+	record, err := loadDrainRecord(d.TM)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if record != nil && record.URL == vtmURL {
+		record.remove()
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(tmCmd)
+}
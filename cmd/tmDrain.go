@@ -0,0 +1,172 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/martinlindner/go-vtm"
+	"github.com/spf13/cobra"
+)
+
+var tmDrainWait time.Duration
+
+// tmDrainCmd represents the tm drain command
+var tmDrainCmd = &cobra.Command{
+	Use:   "drain [tm]",
+	Short: "Move all traffic IPs away from traffic manager [tm]",
+	Long: `Make traffic manager [tm] a passive member of every traffic IP group it
+serves and wait up to --wait for its traffic IPs to move to other members.
+The groups changed are recorded, so tm undrain restores exactly those.
+Draining the last active member of a group needs --force.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("Missing argument(s)")
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		tmDrain(args[0])
+	},
+}
+
+func tmDrain(tm string) {
+	if dryRun {
+		fmt.Println(dryRunC)
+	}
+
+	client := initClient()
+	changes := passiveChanges(&client, func(string) bool { return true }, tm, true)
+
+	if len(changes) == 0 {
+		fmt.Println(tm, "is not an active member of any traffic IP group")
+	}
+
+	checkLastActive(tm, changes)
+
+	// Recorded once the changes are in effect, by the scheduler for
+	// queued changes. Changes reverted with --for need no undrain.
+	update := &drainUpdate{TM: tm}
+	queuedDrain = update
+	if !applyChanges(&client, changes) {
+		return
+	}
+	update.apply(currentConnection().URL, changes)
+
+	if tmDrainWait <= 0 {
+		return
+	}
+
+	waitForTrafficIPs(initAPIClient(), tm, tmDrainWait)
+}
+
+// checkLastActive refuses changes that would make tm passive in a group it
+// is the last active member of, unless --force is given.
+func checkLastActive(tm string, changes []change) {
+	if groups := lastActive(changes); len(groups) > 0 && !force {
+		log.Fatal(tm, " is the last active member of ", strings.Join(groups, ", "), ", use --force to make it passive anyway")
+	}
+}
+
+// lastActive returns the groups changes would leave without an active
+// member.
+func lastActive(changes []change) []string {
+	var groups []string
+
+	for _, c := range changes {
+		var slaves []string
+		if err := json.Unmarshal(c.After, &slaves); err != nil {
+			log.Fatal(err)
+		}
+
+		r := c.object.(*stingray.TrafficIPGroup)
+		active := false
+		for _, m := range stringsOf(r.Basic.Machines) {
+			if !containsString(slaves, m) {
+				active = true
+			}
+		}
+		if !active {
+			groups = append(groups, c.Name)
+		}
+	}
+
+	return groups
+}
+
+// recordDrain adds the groups of changes to the drain record of tm on the vTM
+// at url.
+func recordDrain(tm, url string, changes []change) {
+	record, err := loadDrainRecord(tm)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if record == nil {
+		record = &drainRecord{TM: tm, URL: url}
+	}
+	record.Time = time.Now()
+	for _, c := range changes {
+		if !containsString(record.Groups, c.Name) {
+			record.Groups = append(record.Groups, c.Name)
+		}
+	}
+
+	if err := record.save(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// waitForTrafficIPs waits until tm has lowered all its traffic IPs.
+func waitForTrafficIPs(api *apiClient, tm string, wait time.Duration) {
+	deadline := time.Now().Add(wait)
+
+	for {
+		ips, err := api.trafficIPs(tm)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if len(ips) == 0 {
+			fmt.Println(tm, "hosts no traffic IPs")
+			return
+		}
+
+		if time.Now().After(deadline) {
+			log.Fatal(tm, " still hosts ", strings.Join(ips, ", "), " after ", wait)
+		}
+
+		fmt.Print(tm, ":\tstill hosts ", strings.Join(ips, ", "), "\n")
+		time.Sleep(5 * time.Second)
+	}
+}
+
+func init() {
+	tmCmd.AddCommand(tmDrainCmd)
+
+	tmDrainCmd.Flags().DurationVar(&tmDrainWait, "wait", 5*time.Minute, "Time to wait for the traffic IPs to move, 0 to not wait.")
+	addChangeFlags(tmDrainCmd)
+}
@@ -0,0 +1,85 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+// tmUndrainCmd represents the tm undrain command
+var tmUndrainCmd = &cobra.Command{
+	Use:   "undrain [tm]",
+	Short: "Make traffic manager [tm] active again in the groups tm drain changed",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("Missing argument(s)")
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		tmUndrain(args[0])
+	},
+}
+
+func tmUndrain(tm string) {
+	if dryRun {
+		fmt.Println(dryRunC)
+	}
+
+	record, err := loadDrainRecord(tm)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if record == nil {
+		log.Fatal("No recorded drain of ", tm)
+	}
+	url := currentConnection().URL
+	if record.URL != url {
+		log.Fatal(tm, " was drained through ", record.URL, ", not ", url)
+	}
+
+	fmt.Println(tm, "was drained", record.Time.Local().Format("2006-01-02 15:04:05"))
+
+	client := initClient()
+	changes := passiveChanges(&client, func(group string) bool {
+		return containsString(record.Groups, group)
+	}, tm, false)
+
+	// The record is kept until the changes are in effect, queued changes
+	// remove it when the scheduler applies them. Changes reverted with
+	// --for leave tm drained. Without changes there is nothing to restore.
+	update := &drainUpdate{TM: tm, Undrain: true}
+	queuedDrain = update
+	if applyChanges(&client, changes) || (len(changes) == 0 && !dryRun) {
+		update.apply(url, changes)
+	}
+}
+
+func init() {
+	tmCmd.AddCommand(tmUndrainCmd)
+
+	addChangeFlags(tmUndrainCmd)
+}
@@ -0,0 +1,329 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gobwas/glob"
+	"github.com/mgutz/ansi"
+	"github.com/spf13/cobra"
+)
+
+var (
+	topInterval time.Duration
+	topSort     string
+	topVserver  string
+)
+
+// topCmd represents the top command
+var topCmd = &cobra.Command{
+	Use:   "top [vserver]",
+	Short: "Show a refreshing view of vserver traffic",
+	Long: `Show the vservers matching [vserver] (default all) sorted by connections or
+errors per second, refreshed every --interval, as counted by the traffic
+manager the API URL points to.
+
+Commands, followed by Enter:
+  NUMBER or NAME  show the pool and nodes of a vserver
+  (empty)         back to the vserver list
+  c / e           sort by connections/s or errors/s
+  q               quit`,
+	Run: func(cmd *cobra.Command, args []string) {
+		target := "*"
+		if len(args) > 0 {
+			target = args[0]
+		}
+
+		top(target)
+	},
+}
+
+// vserverRate is the traffic of a vserver between two refreshes.
+type vserverRate struct {
+	name   string
+	conns  int64
+	conn   float64
+	errors float64
+	// measured is false until the vserver has been sampled twice.
+	measured bool
+}
+
+// topView is the state of the top screen.
+type topView struct {
+	api      *apiClient
+	glob     glob.Glob
+	vservers map[string]vserverStatistics
+	nodes    map[string]poolNodeStatistics
+	at       time.Time
+	rates    []vserverRate
+	rows     int
+	detail   string
+	pool     string
+	message  string
+}
+
+// vserverPool returns the pool a vserver sends its traffic to by default.
+func (a *apiClient) vserverPool(name string) (string, error) {
+	var doc struct {
+		Properties struct {
+			Basic struct {
+				Pool string `json:"pool"`
+			} `json:"basic"`
+		} `json:"properties"`
+	}
+	err := a.getJSON("config/active/virtual_servers/"+url.PathEscape(name), &doc)
+
+	return doc.Properties.Basic.Pool, err
+}
+
+// refresh lists and samples the vservers and, when drilled down, the nodes
+// of the vserver's pool, and computes the rates since the previous refresh.
+// Vservers seen for the first time have no rates yet. A failed request is
+// shown in the status line and the previous sample is kept.
+func (v *topView) refresh() {
+	names, err := v.api.statisticsNames("virtual_servers", v.glob)
+	if err != nil {
+		v.message = err.Error()
+		return
+	}
+	vservers, at, err := sampleVserverStats(v.api, names)
+	if err != nil {
+		v.message = err.Error()
+		return
+	}
+	d := at.Sub(v.at)
+
+	v.rates = v.rates[:0]
+	for _, name := range names {
+		a, seen := v.vservers[name]
+		b := vservers[name]
+		r := vserverRate{name: name, conns: b.CurrentConn, measured: seen}
+		if seen {
+			r.conn = perSecond(a.TotalConn, b.TotalConn, d)
+			r.errors = perSecond(a.ConnectionErrors+a.ConnectionFailures, b.ConnectionErrors+b.ConnectionFailures, d)
+		}
+		v.rates = append(v.rates, r)
+	}
+	sort.SliceStable(v.rates, func(i, j int) bool {
+		if topSort == "errors" {
+			return v.rates[i].errors > v.rates[j].errors
+		}
+		return v.rates[i].conn > v.rates[j].conn
+	})
+
+	v.vservers, v.at = vservers, at
+
+	if v.detail != "" {
+		v.refreshNodes()
+	}
+}
+
+func (v *topView) refreshNodes() {
+	nodes, err := v.api.poolNodes(v.pool)
+	if err != nil {
+		v.message = err.Error()
+		return
+	}
+
+	stats := make(map[string]poolNodeStatistics)
+	for _, node := range nodes {
+		s, err := v.api.poolNodeStatistics(v.pool, node)
+		if err != nil {
+			v.message = err.Error()
+			return
+		}
+		stats[node] = s
+	}
+	v.nodes = stats
+}
+
+// drill shows the pool and nodes of the vserver selected by its number in
+// the list or its name.
+func (v *topView) drill(selection string) {
+	name := selection
+	if i, err := strconv.Atoi(selection); err == nil && i > 0 && i <= len(v.rates) {
+		name = v.rates[i-1].name
+	}
+	if _, ok := v.vservers[name]; !ok {
+		v.message = "No vserver " + selection
+		return
+	}
+
+	pool, err := v.api.vserverPool(name)
+	if err != nil {
+		v.message = err.Error()
+		return
+	}
+
+	v.detail, v.pool, v.nodes = name, pool, nil
+	v.refreshNodes()
+}
+
+func nodeStateColor(state string) string {
+	switch state {
+	case "alive":
+		return aliveC
+	case "dead":
+		return deadC
+	}
+
+	return ansi.Color(state, "yellow")
+}
+
+// format formats a rate of r, or "-" until there are two samples.
+func (r vserverRate) format(rate float64, prec int) string {
+	if !r.measured {
+		return "-"
+	}
+
+	return strconv.FormatFloat(rate, 'f', prec, 64)
+}
+
+// render draws the screen, cut to the terminal height.
+func (v *topView) render() {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+
+	if v.detail == "" {
+		fmt.Fprintln(w, "#\tVSERVER\tCURRENT\tCONN/S\tERR/S")
+		for i, r := range v.rates {
+			fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%s\n", i+1, r.name, r.conns, r.format(r.conn, 1), r.format(r.errors, 2))
+		}
+	} else {
+		var r vserverRate
+		for _, r = range v.rates {
+			if r.name == v.detail {
+				break
+			}
+		}
+		fmt.Fprintf(w, "vserver %s\tpool %s\t%s conn/s\t%s err/s\n\n", v.detail, v.pool, r.format(r.conn, 1), r.format(r.errors, 2))
+		fmt.Fprintln(w, "NODE\tCURRENT\tTOTAL\tRESP MEAN\tRESP MAX\tERRORS\tSTATE")
+		for _, node := range sortedNodes(v.nodes) {
+			n := v.nodes[node]
+			fmt.Fprintf(w, "%s\t%d\t%d\t%dms\t%dms\t%d\t%s\n", node, n.CurrentConn, n.TotalConn, n.ResponseMean, n.ResponseMax, n.Errors+n.Failures, nodeStateColor(n.State))
+		}
+	}
+	w.Flush()
+
+	sortedBy := "conn/s"
+	if topSort == "errors" {
+		sortedBy = "err/s"
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if max := v.rows - 4; max > 0 && len(lines) > max {
+		lines = lines[:max]
+	}
+
+	fmt.Print("\033[H\033[2J")
+	fmt.Printf("%s  %s  every %s, sorted by %s\n\n", v.api.conn.URL, v.at.Format("15:04:05"), topInterval, sortedBy)
+	fmt.Println(strings.Join(lines, "\n"))
+	if v.message != "" {
+		fmt.Println(v.message)
+		v.message = ""
+	}
+	fmt.Print("> ")
+}
+
+func sortedNodes(nodes map[string]poolNodeStatistics) []string {
+	names := make([]string, 0, len(nodes))
+	for name := range nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func top(targetVserver string) {
+	if !isTerminal(os.Stdout) {
+		log.Fatal("top needs a terminal")
+	}
+	if topSort != "conn" && topSort != "errors" {
+		log.Fatal("Unknown sort order: ", topSort)
+	}
+
+	v := &topView{api: initAPIClient(), glob: glob.MustCompile(targetVserver)}
+	v.rows = terminalRows()
+	v.refresh()
+	if topVserver != "" {
+		v.drill(topVserver)
+	}
+
+	input := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			input <- strings.TrimSpace(scanner.Text())
+		}
+		close(input)
+	}()
+
+	resize := make(chan os.Signal, 1)
+	notifyResize(resize)
+
+	ticker := time.NewTicker(topInterval)
+	defer ticker.Stop()
+
+	for {
+		v.render()
+
+		select {
+		case <-ticker.C:
+			v.refresh()
+		case <-resize:
+			v.rows = terminalRows()
+		case line, ok := <-input:
+			switch {
+			case !ok || line == "q":
+				fmt.Println()
+				return
+			case line == "c":
+				topSort = "conn"
+			case line == "e":
+				topSort = "errors"
+			case line == "":
+				v.detail = ""
+			default:
+				v.drill(line)
+			}
+		}
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(topCmd)
+
+	topCmd.Flags().DurationVar(&topInterval, "interval", 2*time.Second, "Refresh interval.")
+	topCmd.Flags().StringVar(&topSort, "sort", "conn", "Sort vservers by conn (connections/s) or errors (errors/s).")
+	topCmd.Flags().StringVar(&topVserver, "vserver", "", "Start with the pool and nodes of this vserver.")
+}
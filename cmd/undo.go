@@ -0,0 +1,120 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+// undoCmd represents the undo command
+var undoCmd = &cobra.Command{
+	Use:   "undo [id]",
+	Short: "Revert the operation [id] from the change journal",
+	Long: `Revert the operation [id] from the change journal, restoring the recorded
+previous values. Without [id] the latest operation on the current cluster
+that has not been undone yet is reverted.
+
+Nothing is changed unless every object still holds the value the operation
+set.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		id := ""
+		if len(args) > 0 {
+			id = args[0]
+		}
+
+		undo(id)
+	},
+}
+
+func undo(id string) {
+	if dryRun {
+		fmt.Println(dryRunC)
+	}
+
+	entries, err := loadJournal()
+	if err != nil {
+		log.Fatal(err)
+	}
+	reverted := undone(entries)
+	url := currentConnection().URL
+
+	var entry *journalEntry
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if e.ID == id || (id == "" && e.URL == url && e.UndoOf == "" && !reverted[e.ID]) {
+			entry = e
+			break
+		}
+	}
+
+	if entry == nil && id != "" {
+		log.Fatal("No operation ", id, " in the journal")
+	}
+	if entry == nil {
+		log.Fatal("No operation to undo")
+	}
+	if entry.URL != url {
+		log.Fatalf("Operation %s was made on %s, select that cluster with --profile or --vtmAPIUrl", entry.ID, entry.URL)
+	}
+	if reverted[entry.ID] {
+		fmt.Println("Operation", entry.ID, "has been undone before")
+	}
+
+	fmt.Println("Undoing", entry.ID+":", entry.Command)
+	client := initClient()
+
+	var reverts []change
+	for i := len(entry.Changes) - 1; i >= 0; i-- {
+		reverts = append(reverts, entry.Changes[i].reverse())
+	}
+
+	if err := reload(&client, reverts); err != nil {
+		log.Fatal(err)
+	}
+
+	conflicts, err := checkInOrder(&client, reverts, func(c *change) {
+		fmt.Print(c.Kind, " ", c.Name, ":\t", c.Field, " ", string(c.Before), " -> ", string(c.After), "\n")
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, conflict := range conflicts {
+		fmt.Println(conflict)
+	}
+
+	if len(conflicts) > 0 {
+		log.Fatal("Objects were changed since ", entry.ID, ", not undoing anything")
+	}
+
+	undoEntry := newJournalEntry()
+	undoEntry.UndoOf = entry.ID
+	commitChanges(&client, reverts, undoEntry)
+}
+
+func init() {
+	RootCmd.AddCommand(undoCmd)
+
+	addChangeFlags(undoCmd)
+}
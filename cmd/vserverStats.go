@@ -0,0 +1,146 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gobwas/glob"
+	"github.com/spf13/cobra"
+)
+
+// vserverStatsCmd represents the vserver stats command
+var vserverStatsCmd = &cobra.Command{
+	Use:   "stats [vserver]",
+	Short: "Show traffic counters of [vserver]",
+	Long: `Show current and total connections, bytes in and out and errors of the
+vservers matching [vserver], as counted by the traffic manager the API URL
+points to. With --interval the counters are read twice and rates per second
+are shown as well.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("Missing argument(s)")
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		watch(func(out io.Writer) error {
+			return vserverStats(out, args[0])
+		})
+	},
+}
+
+type vserverRates struct {
+	Conn     float64 `json:"conn"`
+	BytesIn  float64 `json:"bytes_in"`
+	BytesOut float64 `json:"bytes_out"`
+	Errors   float64 `json:"errors"`
+}
+
+type vserverStatsRow struct {
+	Name string `json:"name"`
+	vserverStatistics
+	Rates *vserverRates `json:"rates,omitempty"`
+}
+
+func sampleVserverStats(api *apiClient, names []string) (map[string]vserverStatistics, time.Time, error) {
+	stats := make(map[string]vserverStatistics)
+	for _, name := range names {
+		s, err := api.vserverStatistics(name)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		stats[name] = s
+	}
+
+	return stats, time.Now(), nil
+}
+
+func vserverStats(out io.Writer, targetVserver string) error {
+	vserverGlob := glob.MustCompile(targetVserver)
+	api := initAPIClient()
+
+	progress("Getting vserver statistics from", api.conn.URL)
+	names, err := api.statisticsNames("virtual_servers", vserverGlob)
+	if err != nil {
+		return err
+	}
+	stats, at, err := sampleVserverStats(api, names)
+	if err != nil {
+		return err
+	}
+
+	rows := make([]vserverStatsRow, 0, len(names))
+	for _, name := range names {
+		rows = append(rows, vserverStatsRow{Name: name, vserverStatistics: stats[name]})
+	}
+
+	if statsInterval > 0 {
+		progress("Measuring rates for", statsInterval)
+		time.Sleep(statsInterval)
+
+		after, end, err := sampleVserverStats(api, names)
+		if err != nil {
+			return err
+		}
+		d := end.Sub(at)
+		for i := range rows {
+			a, b := stats[rows[i].Name], after[rows[i].Name]
+			rows[i].vserverStatistics = b
+			rows[i].Rates = &vserverRates{
+				Conn:     perSecond(a.TotalConn, b.TotalConn, d),
+				BytesIn:  perSecond(a.BytesIn, b.BytesIn, d),
+				BytesOut: perSecond(a.BytesOut, b.BytesOut, d),
+				Errors:   perSecond(a.ConnectionErrors+a.ConnectionFailures, b.ConnectionErrors+b.ConnectionFailures, d),
+			}
+		}
+	}
+
+	if jsonOutput() {
+		fprintJSON(out, rows)
+		return nil
+	}
+
+	w := new(tabwriter.Writer)
+	w.Init(out, 0, 5, 2, ' ', 0)
+
+	for _, r := range rows {
+		fmt.Fprint(w, r.Name, ":\t", r.CurrentConn, " current\t", r.TotalConn, " total\t", r.BytesIn, " bytes in\t", r.BytesOut, " bytes out\t", r.ConnectionErrors+r.ConnectionFailures, " errors")
+		if r.Rates != nil {
+			fmt.Fprintf(w, "\t%.1f conn/s\t%.0f in B/s\t%.0f out B/s\t%.2f err/s", r.Rates.Conn, r.Rates.BytesIn, r.Rates.BytesOut, r.Rates.Errors)
+		}
+		fmt.Fprintln(w)
+	}
+	return w.Flush()
+}
+
+func init() {
+	vserverCmd.AddCommand(vserverStatsCmd)
+
+	vserverStatsCmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table or json).")
+	vserverStatsCmd.Flags().DurationVar(&statsInterval, "interval", 0, "Read the counters twice this far apart and show rates.")
+	vserverStatsCmd.Flags().DurationVar(&watchInterval, "watch", 0, "Re-run at this interval, highlighting changed lines.")
+}
@@ -0,0 +1,82 @@
+// Copyright © 2017 Martin Lindner <mlindner@gaba.co.jp>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mgutz/ansi"
+)
+
+// watchInterval is the value of --watch.
+var watchInterval time.Duration
+
+var changedC = ansi.ColorCode("yellow+b")
+
+// watch runs a read command once, writing to stdout, or with --watch
+// repeatedly, redrawing the screen and highlighting lines that changed since
+// the previous run. With --watch it only returns when interrupted, errors
+// are shown in place of the output until the next run.
+func watch(run func(out io.Writer) error) {
+	if watchInterval <= 0 {
+		if err := run(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	var previous []string
+
+	for {
+		lines := watchFrame(run)
+
+		fmt.Print("\033[H\033[2J")
+		fmt.Printf("Every %s: %s\t%s\n\n", watchInterval, strings.Join(os.Args[1:], " "), time.Now().Format("15:04:05"))
+
+		for i, line := range lines {
+			if previous != nil && (i >= len(previous) || previous[i] != line) {
+				// Re-apply the highlight after colors within the line.
+				line = changedC + strings.Replace(line, ansi.Reset, ansi.Reset+changedC, -1) + ansi.Reset
+			}
+			fmt.Println(line)
+		}
+		previous = lines
+
+		time.Sleep(watchInterval)
+	}
+}
+
+// watchFrame runs run and returns its output lines, or the error it failed
+// with.
+func watchFrame(run func(out io.Writer) error) []string {
+	var buf bytes.Buffer
+	if err := run(&buf); err != nil {
+		return []string{"Error: " + err.Error()}
+	}
+
+	return strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+}